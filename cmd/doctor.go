@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/pterm/pterm"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/app"
+	"github.com/spf13/cobra"
+)
+
+var doctorGroupID int64   // Group whose role of the current user is checked
+var doctorProjectID int64 // Project whose role of the current user is checked
+
+// doctorCmd represents the command to check the configuration.
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the configuration and report which features will work",
+	Long: `Check the GitLab URL, its reachability, TLS, the token, its scopes and the role of the current user
+on the group or project given, then report which features will work with this configuration.
+
+Exits with an error if a check failed.`,
+	Run: func(_ *cobra.Command, _ []string) {
+		ctx := context.Background()
+		credential := app.Check{Name: "Credentials", Status: app.CheckOK}
+		token, source, err := resolveToken(ctx)
+		if err != nil {
+			credential.Status, credential.Detail = app.CheckFailed, err.Error()
+		} else {
+			credential.Detail = "token from " + source
+		}
+		diagnosis := &app.Diagnosis{}
+		a, err := app.New(nil, appConfig(token, newLogger()))
+		if err != nil {
+			diagnosis.Checks = append(diagnosis.Checks, app.Check{Name: "Configuration", Status: app.CheckFailed,
+				Detail: err.Error()})
+		} else {
+			diagnosis = a.Diagnose(ctx, doctorGroupID, doctorProjectID)
+		}
+		diagnosis.Checks = append([]app.Check{credential}, diagnosis.Checks...)
+		if err := renderChecks(diagnosis.Checks); err != nil {
+			fmt.Fprintf(os.Stderr, "Error rendering checks: %v\n", err)
+			os.Exit(1)
+		}
+		if len(diagnosis.Features) > 0 {
+			fmt.Println()
+			if err := renderChecks(diagnosis.Features); err != nil {
+				fmt.Fprintf(os.Stderr, "Error rendering features: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if diagnosis.Failed() {
+			os.Exit(1)
+		}
+	},
+}
+
+// renderChecks prints the checks in a table.
+func renderChecks(checks []app.Check) error {
+	tData := pterm.TableData{}
+	if !printNoHeader {
+		tData = append(tData, []string{"Status", "Check", "Detail"})
+	}
+	for _, check := range checks {
+		tData = append(tData, []string{checkStatus(check.Status), check.Name, check.Detail})
+	}
+	table := pterm.DefaultTable
+	if !printNoHeader {
+		table = *table.WithHasHeader()
+	}
+	if err := table.WithData(tData).Render(); err != nil {
+		return fmt.Errorf("failed to render table: %w", err)
+	}
+	return nil
+}
+
+// checkStatus returns the status of a check, colored unless disabled.
+func checkStatus(status string) string {
+	if printNoColor {
+		return status
+	}
+	switch status {
+	case app.CheckOK:
+		return pterm.Green(status)
+	case app.CheckWarning:
+		return pterm.Yellow(status)
+	case app.CheckFailed:
+		return pterm.Red(status)
+	default:
+		return pterm.Gray(status)
+	}
+}
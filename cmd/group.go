@@ -3,13 +3,13 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 
-	"github.com/pterm/pterm"
 	"github.com/sgaunet/gitlab-token-expiration/pkg/app"
 	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
-	"github.com/sgaunet/gitlab-token-expiration/pkg/views"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/progress"
 	"github.com/spf13/cobra"
 	"gitlab.com/gitlab-org/api/client-go"
 )
@@ -20,18 +20,15 @@ var noRecursiveOption bool
 var groupCmd = &cobra.Command{
 	Use:   "group",
 	Short: "List expirable tokens of a group",
-	Long:  `List expirable tokens of a group`,
-	Run: func(_ *cobra.Command, _ []string) {
+	Long:  "List expirable tokens of a group\n\n" + inventoryHelp,
+	Run: func(cmd *cobra.Command, _ []string) {
 		var tokens []dto.Token
-		v := views.NewTableOutput(views.WithColorOption(!printNoColor),
-			views.WithHeaderOption(!printNoHeader),
-			views.WithPrintRevokedOption(printRevoked),
-			views.WithNbDaysBeforeExp(nbDaysBeforeExp),
-		)
-		a := app.NewApp(v, app.WithRevokedToken(printRevoked))
+		v := newTableOutput()
+		p := newProgress()
+		a := newApp(v, app.WithRevokedToken(printRevoked), app.WithOwners(resolveOwners),
+			app.WithRunners(listRunners), app.WithAgents(listAgents), app.WithInventory(inventory),
+			app.WithServiceAccounts(listServiceAccounts), app.WithProgress(p))
 
-		// l := initTrace(os.Getenv("DEBUGLEVEL"))
-		// a.SetLogger(l)
 		ctx := context.Background()
 
 		if gitlabID == 0 {
@@ -47,65 +44,46 @@ var groupCmd = &cobra.Command{
 				os.Exit(1)
 			}
 			tokens, err = a.GetTokensOfGroups(ctx, []*gitlab.Group{group})
-			if err != nil {
-				fmt.Fprintln(os.Stderr, err.Error())
-				os.Exit(1)
-			}
-			if err := v.Render(tokens); err != nil {
-				fmt.Fprintf(os.Stderr, "Error rendering tokens: %v\n", err)
-				os.Exit(1)
-			}
+			skipped := skippedSources(err)
+			saveSnapshot(a, cmd, tokens, skipped)
+			renderTokens(v, tokens, skipped)
 		}
 
 		if !noRecursiveOption {
 			// List tokens of the group and its subgroups and projects
 			// recursive option
-			fmt.Println("Retrieve informations of all subgroups and projects")
-			spinnerInfo, _ := pterm.DefaultSpinner.Start("Retrieve informations of all subgroups and projects")
-
-			actualGroup, err := a.GetGroup(gitlabID)
-			if err != nil {
-				spinnerInfo.Fail("Error while retrieving group informations")
-				fmt.Fprintln(os.Stderr, err.Error())
-				os.Exit(1)
-			}
-			// List tokens of the group and its subgroups and projects
-			groups, err := a.GetSubGroups(gitlabID)
-			if err != nil {
-				spinnerInfo.Fail("Error while retrieving subgroups")
-				fmt.Fprintln(os.Stderr, err.Error())
-				os.Exit(1)
-			}
-			groups = append(groups, actualGroup)
+			var err error
+			tokens, err = getTokensOfGroupRecursively(ctx, a, p, gitlabID)
+			skipped := skippedSources(err)
+			saveSnapshot(a, cmd, tokens, skipped)
+			renderTokens(v, tokens, skipped)
+		}
+	},
+}
 
-			projects, err := a.GetRecursiveProjectsOfGroup(gitlabID)
-			if err != nil {
-				spinnerInfo.Fail("Error while retrieving projects")
-				fmt.Fprintln(os.Stderr, err.Error())
-				os.Exit(1)
-			}
-			spinnerInfo.Success("Groups and projects retrieved")
-			spinnerInfo, _ = pterm.DefaultSpinner.Start("Retrieve tokens of all subgroups and projects")
+// getTokensOfGroupRecursively returns the tokens of the group, its subgroups and their projects.
+// If the tokens of some subgroups or projects cannot be retrieved, the other tokens are returned
+// along with a *app.ScanError listing the skipped sources. The progress is reported to p, which
+// must be the progress of the application.
+func getTokensOfGroupRecursively(ctx context.Context, a *app.App, p *progress.Reporter, groupID int64) ([]dto.Token, error) {
+	p.Start("Listing subgroups and projects")
+	defer p.Stop()
 
-			tokens, err = a.GetTokensOfGroups(ctx, groups)
-			if err != nil {
-				spinnerInfo.Fail("Error while retrieving tokens of groups")
-				fmt.Fprintln(os.Stderr, err.Error())
-				os.Exit(1)
-			}
-			tokensOfProjects, err := a.GetTokensOfProjects(ctx, projects)
-			if err != nil {
-				spinnerInfo.Fail("Error while retrieving tokens of projects")
-				fmt.Fprintln(os.Stderr, err.Error())
-				os.Exit(1)
-			}
-			tokens = append(tokens, tokensOfProjects...)
-			spinnerInfo.Success("Tokens retrieved")
+	actualGroup, err := a.GetGroup(groupID)
+	if err != nil {
+		return nil, err
+	}
+	// List the subgroups and projects of the group
+	groups, projects, projectsErr := a.GetGroupHierarchy(ctx, actualGroup)
+	var scanErr *app.ScanError
+	if projectsErr != nil && !errors.As(projectsErr, &scanErr) {
+		return nil, projectsErr
+	}
+	p.SetTotals(len(groups), len(projects))
+	p.Start("Retrieving tokens")
 
-			if err := v.Render(tokens); err != nil {
-				fmt.Fprintf(os.Stderr, "Error rendering tokens: %v\n", err)
-				os.Exit(1)
-			}
-		}
-	},
+	tokens, groupsErr := a.GetTokensOfGroups(ctx, groups)
+	tokensOfProjects, tokensErr := a.GetTokensOfProjects(ctx, projects)
+	tokens = append(tokens, tokensOfProjects...)
+	return tokens, app.MergeScanErrors(projectsErr, groupsErr, tokensErr)
 }
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"github.com/spf13/cobra"
+)
+
+var allUsersOption bool           // List the SSH keys of all users (admin)
+var instanceDeployKeysOption bool // List the instance deploy keys (admin)
+
+// keysCmd represents the command to list SSH keys and deploy keys.
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "List SSH keys with their expiration date",
+	Long: `List the SSH keys of the current user, or of all users with --all-users,
+and the deploy keys of the instance with --instance-deploy-keys.
+
+--all-users and --instance-deploy-keys require administrator access.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		v := newTableOutput()
+		a := newApp(v)
+		ctx := context.Background()
+
+		var tokens []dto.Token
+		var err error
+		if allUsersOption {
+			tokens, err = a.GetSSHKeysOfAllUsers(ctx)
+		} else {
+			tokens, err = a.GetSSHKeys(ctx)
+		}
+		// The keys of the users that could not be listed are missing
+		skipped := skippedSources(err)
+		if instanceDeployKeysOption {
+			deployKeys, err := a.GetInstanceDeployKeys(ctx)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			tokens = append(tokens, deployKeys...)
+		}
+		saveSnapshot(a, cmd, tokens, skipped)
+		renderTokens(v, tokens, skipped)
+	},
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/app"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/filter"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/policy"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/snapshot"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/views"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// outputFlags are the flags changing only the output of a scan, not the tokens retrieved.
+var outputFlags = []string{
+	"revoked", "no-header", "no-color", "days-before-expiration", "summary", "never-expires", "stale",
+	"snapshot", "snapshot-dir", "check", "quiet", "progress", "strict",
+}
+
+// newTableOutput returns the table renderer configured with the command line flags.
+func newTableOutput() views.TableOutput {
+	return views.NewTableOutput(views.WithColorOption(!printNoColor),
+		views.WithHeaderOption(!printNoHeader),
+		views.WithPrintRevokedOption(printRevoked),
+		views.WithNbDaysBeforeExp(nbDaysBeforeExp),
+		views.WithSummaryOption(printSummary),
+	)
+}
+
+// tokenFilters returns the filters selected with the command line flags.
+func tokenFilters() []filter.Filter {
+	var filters []filter.Filter
+	if neverExpiresOnly {
+		filters = append(filters, filter.NeverExpires())
+	}
+	if staleDays > 0 {
+		filters = append(filters, filter.Stale(time.Now(), staleDays))
+	}
+	return filters
+}
+
+// renderTokens filters and renders the tokens, reports the sources skipped during the scan,
+// then checks the tokens against the policy if requested. It exits the program on error,
+// on policy violation, or if sources were skipped in strict mode.
+func renderTokens(v views.Renderer, tokens []dto.Token, skipped []app.SkippedSource) {
+	tokens = filter.Apply(tokens, tokenFilters()...)
+	if err := v.Render(tokens); err != nil {
+		fmt.Fprintf(os.Stderr, "Error rendering tokens: %v\n", err)
+		os.Exit(1)
+	}
+	reportSkippedSources(skipped)
+	if checkPolicy {
+		violations := policy.Check(tokens, policy.NoExpiration())
+		for _, violation := range violations {
+			fmt.Fprintln(os.Stderr, violation.String())
+		}
+		if len(violations) > 0 {
+			fmt.Fprintf(os.Stderr, "%d policy violation(s) found\n", len(violations))
+			os.Exit(1)
+		}
+	}
+	if strict && len(skipped) > 0 {
+		os.Exit(1)
+	}
+}
+
+// skippedSources returns the sources skipped during a scan that partially failed.
+// It exits the program if the scan failed.
+func skippedSources(err error) []app.SkippedSource {
+	if err == nil {
+		return nil
+	}
+	var scanErr *app.ScanError
+	if !errors.As(err, &scanErr) {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	return scanErr.Skipped
+}
+
+// reportSkippedSources prints the sources skipped during the scan with the reason.
+func reportSkippedSources(skipped []app.SkippedSource) {
+	if len(skipped) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%d source(s) skipped, their tokens are missing from the report:\n", len(skipped))
+	for _, s := range skipped {
+		fmt.Fprintf(os.Stderr, "  - %s\n", s.String())
+	}
+}
+
+// snapshotStore returns the snapshot store of the directory given on the command line,
+// or of the default directory.
+func snapshotStore() (*snapshot.Store, error) {
+	dir := snapshotDir
+	if dir == "" {
+		var err error
+		dir, err = snapshot.DefaultDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get snapshot directory: %w", err)
+		}
+	}
+	return snapshot.NewStore(dir), nil
+}
+
+// snapshotScope returns the scope of the scan run by the command: its name and the flags
+// changing the tokens retrieved, e.g. "group --id=42 --runners".
+func snapshotScope(cmd *cobra.Command) string {
+	var flags []string
+	cmd.LocalNonPersistentFlags().Visit(func(f *pflag.Flag) {
+		if slices.Contains(outputFlags, f.Name) {
+			return
+		}
+		if f.Value.Type() == "bool" && f.Value.String() == "true" {
+			flags = append(flags, "--"+f.Name)
+			return
+		}
+		flags = append(flags, fmt.Sprintf("--%s=%s", f.Name, f.Value))
+	})
+	slices.Sort(flags)
+	return strings.Join(append([]string{cmd.Name()}, flags...), " ")
+}
+
+// saveSnapshot saves the tokens of the scan in the snapshot store if requested.
+// The scans that skipped sources are not saved: their diff would report the tokens of the
+// skipped sources as removed. It exits the program on error.
+func saveSnapshot(a *app.App, cmd *cobra.Command, tokens []dto.Token, skipped []app.SkippedSource) {
+	if !takeSnapshot {
+		return
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintf(os.Stderr, "Snapshot not saved: %d source(s) skipped, the scan is incomplete\n", len(skipped))
+		return
+	}
+	store, err := snapshotStore()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	name, err := store.Save(snapshot.New(a.BaseURL(), snapshotScope(cmd), tokens))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Snapshot %s saved\n", name)
+}
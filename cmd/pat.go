@@ -6,7 +6,6 @@ import (
 	"os"
 
 	"github.com/sgaunet/gitlab-token-expiration/pkg/app"
-	"github.com/sgaunet/gitlab-token-expiration/pkg/views"
 	"github.com/spf13/cobra"
 )
 
@@ -15,16 +14,10 @@ var patCmd = &cobra.Command{
 	Use:   "pat",
 	Short: "List gitlab personal access tokens",
 	Long:  `List personal access tokens from gitlab`,
-	Run: func(_ *cobra.Command, _ []string) {
-		v := views.NewTableOutput(views.WithColorOption(!printNoColor),
-			views.WithHeaderOption(!printNoHeader),
-			views.WithPrintRevokedOption(printRevoked),
-			views.WithNbDaysBeforeExp(nbDaysBeforeExp),
-		)
-		a := app.NewApp(v, app.WithRevokedToken(printRevoked))
+	Run: func(cmd *cobra.Command, _ []string) {
+		v := newTableOutput()
+		a := newApp(v, app.WithRevokedToken(printRevoked), app.WithOwners(resolveOwners))
 
-		// l := initTrace(os.Getenv("DEBUGLEVEL"))
-		// a.SetLogger(l)
 		ctx := context.Background()
 
 		tokens, err := a.GetPersonalAccessTokens(ctx)
@@ -32,10 +25,8 @@ var patCmd = &cobra.Command{
 			fmt.Fprintln(os.Stderr, err.Error())
 			os.Exit(1)
 		}
-		if err := v.Render(tokens); err != nil {
-			fmt.Fprintf(os.Stderr, "Error rendering tokens: %v\n", err)
-			os.Exit(1)
-		}
+		saveSnapshot(a, cmd, tokens, nil)
+		renderTokens(v, tokens, nil)
 	},
 }
 
@@ -6,7 +6,6 @@ import (
 	"os"
 
 	"github.com/sgaunet/gitlab-token-expiration/pkg/app"
-	"github.com/sgaunet/gitlab-token-expiration/pkg/views"
 	"github.com/spf13/cobra"
 	"gitlab.com/gitlab-org/api/client-go"
 )
@@ -15,17 +14,12 @@ import (
 var projectCmd = &cobra.Command{
 	Use:   "project",
 	Short: "List expirable tokens of a project",
-	Long:  `List expirable tokens of a project`,
-	Run: func(_ *cobra.Command, _ []string) {
-		v := views.NewTableOutput(views.WithColorOption(!printNoColor),
-			views.WithHeaderOption(!printNoHeader),
-			views.WithPrintRevokedOption(printRevoked),
-			views.WithNbDaysBeforeExp(nbDaysBeforeExp),
-		)
-		a := app.NewApp(v, app.WithRevokedToken(printRevoked))
+	Long:  "List expirable tokens of a project\n\n" + inventoryHelp,
+	Run: func(cmd *cobra.Command, _ []string) {
+		v := newTableOutput()
+		a := newApp(v, app.WithRevokedToken(printRevoked), app.WithOwners(resolveOwners),
+			app.WithRunners(listRunners), app.WithAgents(listAgents), app.WithInventory(inventory))
 
-		// l := initTrace(os.Getenv("DEBUGLEVEL"))
-		// a.SetLogger(l)
 		ctx := context.Background()
 
 		if gitlabID == 0 {
@@ -39,13 +33,8 @@ var projectCmd = &cobra.Command{
 			os.Exit(1)
 		}
 		tokens, err := a.GetTokensOfProjects(ctx, []*gitlab.Project{project})
-		if err != nil {
-			fmt.Fprintln(os.Stderr, err.Error())
-			os.Exit(1)
-		}
-		if err := v.Render(tokens); err != nil {
-			fmt.Fprintf(os.Stderr, "Error rendering tokens: %v\n", err)
-			os.Exit(1)
-		}
+		skipped := skippedSources(err)
+		saveSnapshot(a, cmd, tokens, skipped)
+		renderTokens(v, tokens, skipped)
 	},
 }
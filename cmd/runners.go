@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/app"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"github.com/spf13/cobra"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+var runnersGroupID int64   // Group whose runners are listed (recursive)
+var runnersProjectID int64 // Project whose runners are listed
+
+// runnersCmd represents the command to list the runners with the expiration date of their token.
+var runnersCmd = &cobra.Command{
+	Use:   "runners",
+	Short: "List runners with the expiration date of their authentication token",
+	Long: `List the runners of a group and its subgroups and projects with --group,
+of a project with --project, or of the instance otherwise (requires administrator access).
+
+Runner authentication tokens expire according to the runner token expiration settings
+of the instance, group or project.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		v := newTableOutput()
+		a := newApp(v, app.WithRevokedToken(printRevoked))
+		ctx := context.Background()
+
+		var tokens []dto.Token
+		var err error
+		switch {
+		case runnersGroupID != 0:
+			tokens, err = getRunnersOfGroupRecursively(ctx, a, runnersGroupID)
+		case runnersProjectID != 0:
+			var project *gitlab.Project
+			project, err = a.GetProject(runnersProjectID)
+			if err == nil {
+				tokens, err = a.GetRunnersOfProjects(ctx, []*gitlab.Project{project})
+			}
+		default:
+			tokens, err = a.GetInstanceRunners(ctx)
+		}
+		skipped := skippedSources(err)
+		saveSnapshot(a, cmd, tokens, skipped)
+		renderTokens(v, tokens, skipped)
+	},
+}
+
+// getRunnersOfGroupRecursively returns the runners of the group, its subgroups and their projects.
+func getRunnersOfGroupRecursively(ctx context.Context, a *app.App, groupID int64) ([]dto.Token, error) {
+	group, err := a.GetGroup(groupID)
+	if err != nil {
+		return nil, err
+	}
+	groups, projects, projectsErr := a.GetGroupHierarchy(ctx, group)
+	var scanErr *app.ScanError
+	if projectsErr != nil && !errors.As(projectsErr, &scanErr) {
+		return nil, projectsErr
+	}
+
+	tokens, groupsErr := a.GetRunnersOfGroups(ctx, groups)
+	runnersOfProjects, runnersErr := a.GetRunnersOfProjects(ctx, projects)
+	return append(tokens, runnersOfProjects...), app.MergeScanErrors(projectsErr, groupsErr, runnersErr)
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/app"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/snapshot"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/views"
+	"github.com/spf13/cobra"
+)
+
+const minSnapshotsToDiff = 2
+
+// snapshotsCmd represents the command to list the saved snapshots.
+var snapshotsCmd = &cobra.Command{
+	Use:   "snapshots",
+	Short: "List saved snapshots",
+	Long:  `List the snapshots saved with the --snapshot option of the group, project and pat commands`,
+	Run: func(_ *cobra.Command, _ []string) {
+		store, err := snapshotStore()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		snapshots, err := store.List("")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		tData := pterm.TableData{}
+		if !printNoHeader {
+			tData = append(tData, []string{"Name", "Taken at", "Instance", "Scope", "Tokens"})
+		}
+		for _, snap := range snapshots {
+			tData = append(tData, []string{snap.Name, snap.TakenAt.Local().Format(time.DateTime),
+				snap.Instance, snap.Scope, strconv.Itoa(len(snap.Tokens))})
+		}
+		table := pterm.DefaultTable
+		if !printNoHeader {
+			table = *table.WithHasHeader()
+		}
+		if err := table.WithData(tData).Render(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error rendering snapshots: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// diffCmd represents the command to compare two snapshots.
+var diffCmd = &cobra.Command{
+	Use:   "diff [old-snapshot] [new-snapshot]",
+	Short: "Show the changes of the tokens between two snapshots",
+	Long: `Show the tokens created, revoked, removed, rotated, expired or whose expiration date changed
+between two snapshots.
+
+Without argument, the most recent snapshot of the GitLab instance is compared to the previous one
+of the same scope, i.e. taken by the same command with the same ID and scan options.
+With one argument, the given snapshot is compared to the most recent one of the same scope.`,
+	Args: cobra.MaximumNArgs(minSnapshotsToDiff),
+	Run: func(_ *cobra.Command, args []string) {
+		store, err := snapshotStore()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		oldSnap, newSnap, err := snapshotsToDiff(store, args)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		changes, err := snapshot.Diff(oldSnap, newSnap)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Changes between %s and %s\n", oldSnap.Name, newSnap.Name)
+		v := views.NewTableOutput(views.WithColorOption(!printNoColor),
+			views.WithHeaderOption(!printNoHeader))
+		if err := v.RenderChanges(changes); err != nil {
+			fmt.Fprintf(os.Stderr, "Error rendering changes: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// snapshotsToDiff returns the snapshots to compare according to the arguments of the diff command.
+func snapshotsToDiff(store *snapshot.Store, args []string) (snapshot.Snapshot, snapshot.Snapshot, error) {
+	if len(args) == minSnapshotsToDiff {
+		oldSnap, err := store.Load(args[0])
+		if err != nil {
+			return snapshot.Snapshot{}, snapshot.Snapshot{}, fmt.Errorf("failed to load snapshot: %w", err)
+		}
+		newSnap, err := store.Load(args[1])
+		if err != nil {
+			return snapshot.Snapshot{}, snapshot.Snapshot{}, fmt.Errorf("failed to load snapshot: %w", err)
+		}
+		return oldSnap, newSnap, nil
+	}
+
+	// The snapshots are listed without calling the API, so no credential is needed
+	a, err := app.New(nil, app.Config{BaseURL: os.Getenv("GITLAB_URI")})
+	if err != nil {
+		return snapshot.Snapshot{}, snapshot.Snapshot{}, err
+	}
+	instance := a.BaseURL()
+	snapshots, err := store.List(instance)
+	if err != nil {
+		return snapshot.Snapshot{}, snapshot.Snapshot{}, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	if len(args) == 1 {
+		oldSnap, err := store.Load(args[0])
+		if err != nil {
+			return snapshot.Snapshot{}, snapshot.Snapshot{}, fmt.Errorf("failed to load snapshot: %w", err)
+		}
+		snapshots = snapshot.SameScope(snapshots, oldSnap.Scope)
+		// The snapshot is not compared with itself when it is the most recent one
+		if len(snapshots) == 0 || snapshots[len(snapshots)-1].Name == oldSnap.Name {
+			return snapshot.Snapshot{}, snapshot.Snapshot{},
+				fmt.Errorf("no snapshot of %s with scope %q newer than %s to compare with",
+					instance, oldSnap.Scope, oldSnap.Name)
+		}
+		return oldSnap, snapshots[len(snapshots)-1], nil
+	}
+	if len(snapshots) > 0 {
+		snapshots = snapshot.SameScope(snapshots, snapshots[len(snapshots)-1].Scope)
+	}
+	if len(snapshots) < minSnapshotsToDiff {
+		return snapshot.Snapshot{}, snapshot.Snapshot{},
+			fmt.Errorf("at least %d snapshots of %s with the same scope are required", minSnapshotsToDiff, instance)
+	}
+	return snapshots[len(snapshots)-2], snapshots[len(snapshots)-1], nil
+}
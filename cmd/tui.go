@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/app"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/tui"
+	"github.com/spf13/cobra"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// DefaultRotateDays is the default validity in days of the tokens rotated from the user interface.
+const DefaultRotateDays = 90
+
+// maxRotateDays is the maximum validity of a token allowed by GitLab.
+const maxRotateDays = 400
+
+var tuiGroupID int64   // Gitlab group ID to browse
+var tuiProjectID int64 // Gitlab project ID to browse
+var rotateDays uint    // Validity in days of the rotated tokens
+
+// tuiCmd represents the command to browse tokens in an interactive terminal user interface.
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Browse tokens in an interactive terminal user interface",
+	Long: `Browse tokens of a group (--group), a project (--project) or your personal access tokens
+in an interactive terminal user interface.
+
+The list can be searched and filtered by type, source and status. A token can be opened in the
+browser, rotated or revoked after confirmation.`,
+	Run: func(_ *cobra.Command, _ []string) {
+		p := newProgress()
+		a := newApp(nil, app.WithRevokedToken(printRevoked), app.WithOwners(resolveOwners), app.WithProgress(p))
+		ctx := context.Background()
+
+		var tokens []dto.Token
+		var err error
+		switch {
+		case tuiGroupID != 0:
+			tokens, err = getTokensOfGroupRecursively(ctx, a, p, tuiGroupID)
+		case tuiProjectID != 0:
+			var project *gitlab.Project
+			project, err = a.GetProject(tuiProjectID)
+			if err == nil {
+				tokens, err = a.GetTokensOfProjects(ctx, []*gitlab.Project{project})
+			}
+		default:
+			tokens, err = a.GetPersonalAccessTokens(ctx)
+		}
+		skipped := skippedSources(err)
+		if !printRevoked {
+			tokens = withoutRevoked(tokens)
+		}
+
+		m := tui.NewModel(tokens, &tuiActions{ctx: ctx, app: a}, nbDaysBeforeExp)
+		if err := tui.Run(m); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		reportSkippedSources(skipped)
+	},
+}
+
+// tuiActions performs the actions triggered from the user interface with the application.
+type tuiActions struct {
+	ctx context.Context //nolint:containedctx // the actions are triggered by key presses
+	app *app.App
+}
+
+func (t *tuiActions) Rotate(token dto.Token) (dto.Token, string, error) {
+	var expiresAt time.Time
+	if rotateDays > 0 {
+		expiresAt = time.Now().AddDate(0, 0, int(min(rotateDays, uint(maxRotateDays))))
+	}
+	newToken, secret, err := t.app.RotateToken(t.ctx, token, expiresAt)
+	if err != nil {
+		return dto.Token{}, "", fmt.Errorf("rotate: %w", err)
+	}
+	return newToken, secret, nil
+}
+
+func (t *tuiActions) Revoke(token dto.Token) error {
+	if err := t.app.RevokeToken(t.ctx, token); err != nil {
+		return fmt.Errorf("revoke: %w", err)
+	}
+	return nil
+}
+
+func (t *tuiActions) Open(url string) error {
+	if err := tui.OpenBrowser(url); err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+	return nil
+}
+
+// withoutRevoked returns the tokens that are not revoked.
+func withoutRevoked(tokens []dto.Token) []dto.Token {
+	res := make([]dto.Token, 0, len(tokens))
+	for _, token := range tokens {
+		if !token.Revoked {
+			res = append(res, token)
+		}
+	}
+	return res
+}
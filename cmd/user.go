@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/app"
+	"github.com/spf13/cobra"
+)
+
+var userID int64                 // ID of the user whose credentials are listed (admin)
+var oauthApplicationsOption bool // List the OAuth applications of the instance (admin)
+
+// userCmd represents the command to list the credentials of a user.
+var userCmd = &cobra.Command{
+	Use:   "user",
+	Short: "List the credentials of a user",
+	Long: `List the credentials of the current user, or of another user with --id:
+personal access tokens, SSH keys, GPG keys and, for administrators, impersonation tokens.
+
+GitLab does not expose the expiration date of GPG keys, they are listed as non-expiring.
+GitLab does not expose the OAuth applications authorized by a user either: --oauth-applications
+lists the OAuth applications registered on the instance instead, whose secrets never expire.
+Feed tokens and incoming email tokens are not listed: GitLab does not expose them through its API.
+They do not expire; reset them from the access tokens page of the user settings if needed.
+
+--id and --oauth-applications require administrator access.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		v := newTableOutput()
+		a := newApp(v, app.WithRevokedToken(printRevoked),
+			app.WithOAuthApplications(oauthApplicationsOption))
+		ctx := context.Background()
+
+		tokens, err := a.GetUserCredentials(ctx, userID)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		saveSnapshot(a, cmd, tokens, nil)
+		renderTokens(v, tokens, nil)
+	},
+}
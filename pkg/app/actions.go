@@ -0,0 +1,110 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// ErrUnsupportedAction is returned when an action is not supported for a type of token.
+var ErrUnsupportedAction = errors.New("action not supported for this token")
+
+// RotateToken rotates the token: GitLab revokes it and creates a new token with the same settings.
+// The new token expires at expiresAt, or at the default expiration date of GitLab if expiresAt is zero.
+// It returns the new token and its secret value, which GitLab will never return again.
+func (a *App) RotateToken(_ context.Context, token dto.Token, expiresAt time.Time) (dto.Token, string, error) {
+	if err := a.checkClient(); err != nil {
+		return dto.Token{}, "", err
+	}
+	var opt *gitlab.ISOTime
+	if !expiresAt.IsZero() {
+		isoTime := gitlab.ISOTime(expiresAt)
+		opt = &isoTime
+	}
+
+	var newToken dto.Token
+	var secret string
+	switch {
+	case token.Type == dto.TypeAccessToken && token.SourceKind == dto.SourceKindProject:
+		t, _, err := a.gitlabClient.ProjectAccessTokens.RotateProjectAccessToken(token.SourceID, token.ID,
+			&gitlab.RotateProjectAccessTokenOptions{ExpiresAt: opt})
+		if err != nil {
+			return dto.Token{}, "", fmt.Errorf("failed to rotate project access token %d: %w", token.ID, err)
+		}
+		newToken, secret = ConvertProjectAccessTokenToDTOToken(t), t.Token
+	case token.Type == dto.TypeAccessToken && token.SourceKind == dto.SourceKindGroup:
+		t, _, err := a.gitlabClient.GroupAccessTokens.RotateGroupAccessToken(token.SourceID, token.ID,
+			&gitlab.RotateGroupAccessTokenOptions{ExpiresAt: opt})
+		if err != nil {
+			return dto.Token{}, "", fmt.Errorf("failed to rotate group access token %d: %w", token.ID, err)
+		}
+		newToken, secret = ConvertGroupAccessTokenToDTOToken(t), t.Token
+	case token.Type == dto.TypePersonalAccessToken:
+		t, _, err := a.gitlabClient.PersonalAccessTokens.RotatePersonalAccessTokenByID(token.ID,
+			&gitlab.RotatePersonalAccessTokenOptions{ExpiresAt: opt})
+		if err != nil {
+			return dto.Token{}, "", fmt.Errorf("failed to rotate personal access token %d: %w", token.ID, err)
+		}
+		newToken, secret = ConvertPersonalGitlabTokenToDTOToken(t), t.Token
+	case token.Type == dto.TypeServiceAccountToken:
+		t, _, err := a.gitlabClient.Groups.RotateServiceAccountPersonalAccessToken(token.SourceID, token.OwnerID,
+			token.ID, &gitlab.RotateServiceAccountPersonalAccessTokenOptions{ExpiresAt: opt})
+		if err != nil {
+			return dto.Token{}, "", fmt.Errorf("failed to rotate service account token %d: %w", token.ID, err)
+		}
+		newToken, secret = ConvertPersonalGitlabTokenToDTOToken(t), t.Token
+		newToken.Type, newToken.SourceKind, newToken.OwnerID = token.Type, token.SourceKind, token.OwnerID
+	case token.Type == dto.TypeRunnerToken:
+		// The runner keeps its ID, only its authentication token changes
+		t, _, err := a.gitlabClient.Runners.ResetRunnerAuthenticationToken(token.ID)
+		if err != nil {
+			return dto.Token{}, "", fmt.Errorf("failed to reset authentication token of runner %d: %w", token.ID, err)
+		}
+		newToken = token
+		if t.Token != nil {
+			secret = *t.Token
+		}
+		newToken.ExpiresAt = formatDate(t.TokenExpiresAt)
+	default:
+		return dto.Token{}, "", fmt.Errorf("%w: rotate %s", ErrUnsupportedAction, token.Type)
+	}
+
+	// Keep the information retrieved during the scan
+	newToken.Source = token.Source
+	newToken.SourceID = token.SourceID
+	newToken.WebURL = token.WebURL
+	newToken.Owner = token.Owner
+	newToken.Contacts = token.Contacts
+	return newToken, secret, nil
+}
+
+// RevokeToken revokes the token. ErrUnsupportedAction is returned for the credentials that GitLab can
+// only delete, irreversibly (see dto.Token.CanRevoke): they are never deleted.
+func (a *App) RevokeToken(_ context.Context, token dto.Token) error {
+	if err := a.checkClient(); err != nil {
+		return err
+	}
+	var err error
+	switch {
+	case token.Type == dto.TypeAccessToken && token.SourceKind == dto.SourceKindProject:
+		_, err = a.gitlabClient.ProjectAccessTokens.RevokeProjectAccessToken(token.SourceID, token.ID)
+	case token.Type == dto.TypeAccessToken && token.SourceKind == dto.SourceKindGroup:
+		_, err = a.gitlabClient.GroupAccessTokens.RevokeGroupAccessToken(token.SourceID, token.ID)
+	case token.Type == dto.TypePersonalAccessToken:
+		_, err = a.gitlabClient.PersonalAccessTokens.RevokePersonalAccessTokenByID(token.ID)
+	case token.Type == dto.TypeServiceAccountToken:
+		_, err = a.gitlabClient.Groups.RevokeServiceAccountPersonalAccessToken(token.SourceID, token.OwnerID, token.ID)
+	case token.Type == dto.TypeImpersonationToken:
+		_, err = a.gitlabClient.Users.RevokeImpersonationToken(token.SourceID, token.ID)
+	default:
+		return fmt.Errorf("%w: revoke %s", ErrUnsupportedAction, token.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to revoke %s %d: %w", token.Type, token.ID, err)
+	}
+	return nil
+}
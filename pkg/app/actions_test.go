@@ -0,0 +1,114 @@
+package app_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/app"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApp_RotateToken(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v4/projects/1/access_tokens/10/rotate", func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.URL.RawQuery+readBody(r), "2030-01-01")
+		fmt.Fprint(w, `{"id": 11, "name": "ci", "expires_at": "2030-01-01", "token": "glpat-new"}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL))
+	token := dto.Token{ID: 10, Name: "ci", Type: dto.TypeAccessToken, Source: "group/project",
+		SourceKind: dto.SourceKindProject, SourceID: 1}
+
+	newToken, secret, err := a.RotateToken(context.Background(), token,
+		time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, "glpat-new", secret)
+	assert.Equal(t, int64(11), newToken.ID)
+	assert.Equal(t, "group/project", newToken.Source)
+	assert.Equal(t, "2030-01-01", newToken.ExpiresAt)
+}
+
+func TestApp_ServiceAccountTokenActions(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v4/groups/1/service_accounts/100/personal_access_tokens/10/rotate",
+		func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, `{"id": 11, "name": "release", "user_id": 100, "expires_at": "2030-01-01", "token": "glpat-new"}`)
+		})
+	mux.HandleFunc("DELETE /api/v4/groups/1/service_accounts/100/personal_access_tokens/11",
+		func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL))
+	token := dto.Token{ID: 10, Name: "release", Type: dto.TypeServiceAccountToken, Source: "parent",
+		SourceKind: dto.SourceKindGroup, SourceID: 1, OwnerID: 100}
+
+	newToken, secret, err := a.RotateToken(context.Background(), token, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, "glpat-new", secret)
+	assert.Equal(t, int64(11), newToken.ID)
+	assert.Equal(t, dto.TypeServiceAccountToken, newToken.Type)
+	assert.Equal(t, dto.SourceKindGroup, newToken.SourceKind)
+	assert.Equal(t, int64(100), newToken.OwnerID)
+
+	err = a.RevokeToken(context.Background(), newToken)
+	assert.NoError(t, err)
+}
+
+func TestApp_UnsupportedActions(t *testing.T) {
+	a := app.NewApp(&MockRenderer{})
+	token := dto.Token{ID: 10, Type: dto.TypeDeployToken, SourceKind: dto.SourceKindProject, SourceID: 1}
+
+	_, _, err := a.RotateToken(context.Background(), token, time.Time{})
+	assert.ErrorIs(t, err, app.ErrUnsupportedAction)
+	err = a.RevokeToken(context.Background(), dto.Token{Type: "unknown"})
+	assert.ErrorIs(t, err, app.ErrUnsupportedAction)
+}
+
+func TestApp_RevokeToken_NeverDeletes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL))
+
+	for _, tokenType := range []string{dto.TypeDeployToken, dto.TypePipelineTrigger, dto.TypeDeployKey,
+		dto.TypeSSHKey, dto.TypeGPGKey, dto.TypeRunnerToken, dto.TypeWebhook} {
+		t.Run(tokenType, func(t *testing.T) {
+			token := dto.Token{ID: 10, Type: tokenType, SourceKind: dto.SourceKindProject, SourceID: 1}
+			err := a.RevokeToken(context.Background(), token)
+			assert.ErrorIs(t, err, app.ErrUnsupportedAction)
+		})
+	}
+}
+
+func TestApp_RevokeToken(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("DELETE /api/v4/groups/2/access_tokens/10", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL))
+
+	err := a.RevokeToken(context.Background(),
+		dto.Token{ID: 10, Type: dto.TypeAccessToken, SourceKind: dto.SourceKindGroup, SourceID: 2})
+	assert.NoError(t, err)
+}
+
+func readBody(r *http.Request) string {
+	body, _ := io.ReadAll(r.Body)
+	return string(body)
+}
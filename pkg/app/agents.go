@@ -0,0 +1,65 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// agentTokenStatusRevoked is the status of a revoked agent token.
+const agentTokenStatusRevoked = "revoked"
+
+// agentsSettingsPath is the path of the page listing the agents for Kubernetes of a project.
+const agentsSettingsPath = "/-/cluster_agents/"
+
+// WithAgents includes the tokens of the agents for Kubernetes in the tokens of the projects.
+// It requires one additional API call per agent.
+func WithAgents(listAgents bool) Option {
+	return func(a *App) {
+		a.listAgents = listAgents
+	}
+}
+
+// getAgentTokensOfProject returns the tokens of the agents for Kubernetes registered with a project.
+func (a *App) getAgentTokensOfProject(project *gitlab.Project) ([]dto.Token, error) {
+	agents, err := gitlab.ScanAndCollect(func(p gitlab.PaginationOptionFunc) ([]*gitlab.Agent, *gitlab.Response, error) {
+		return a.gitlabClient.ClusterAgents.ListAgents(project.ID, nil, p)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agents for project %d: %w", project.ID, err)
+	}
+
+	var tokens []dto.Token
+	for _, agent := range agents {
+		agentTokens, err := gitlab.ScanAndCollect(func(p gitlab.PaginationOptionFunc) ([]*gitlab.AgentToken, *gitlab.Response, error) {
+			return a.gitlabClient.ClusterAgents.ListAgentTokens(project.ID, agent.ID, nil, p)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tokens of agent %s for project %d: %w", agent.Name, project.ID, err)
+		}
+		dtoTokens := ConvertAgentTokenToDTOTokens(agent, agentTokens)
+		// Add the source
+		for i := range dtoTokens {
+			dtoTokens[i].Source = project.PathWithNamespace
+			dtoTokens[i].SourceID = project.ID
+			dtoTokens[i].WebURL = project.WebURL + agentsSettingsPath + agent.Name
+		}
+		if a.resolveOwners {
+			for i, agentToken := range agentTokens {
+				if agentToken.CreatedByUserID == 0 {
+					continue
+				}
+				u, err := a.user(agentToken.CreatedByUserID)
+				if err != nil {
+					// The token is listed without owner
+					a.log.Warn("owner of agent token not resolved", "agent", agent.Name, "error", err)
+					continue
+				}
+				dtoTokens[i].Owner = u.Username
+			}
+		}
+		tokens = append(tokens, dtoTokens...)
+	}
+	return tokens, nil
+}
@@ -0,0 +1,62 @@
+package app_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/app"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestApp_GetTokensOfProjects_WithAgents(t *testing.T) {
+	mux := http.NewServeMux()
+	for _, path := range []string{"access_tokens", "triggers", "deploy_keys"} {
+		mux.HandleFunc("/api/v4/projects/1/"+path, func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, `[]`)
+		})
+	}
+	mux.HandleFunc("/api/v4/projects/1/members/all", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[{"id": 1, "username": "alice", "access_level": 40}]`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/cluster_agents", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[{"id": 3, "name": "production"}]`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/cluster_agents/3/tokens", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[
+			{"id": 30, "name": "main", "agent_id": 3, "status": "active", "created_by_user_id": 2,
+			 "created_at": "2023-05-01T08:00:00Z", "last_used_at": "2024-11-15T10:30:00Z"},
+			{"id": 31, "name": "old", "agent_id": 3, "status": "revoked"}
+		]`)
+	})
+	mux.HandleFunc("/api/v4/users/2", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"id": 2, "username": "bob"}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL), app.WithAgents(true), app.WithOwners(true))
+	project := &gitlab.Project{ID: 1, PathWithNamespace: "group/project", WebURL: "https://gitlab.example.com/group/project"}
+	tokens, err := a.GetTokensOfProjects(context.Background(), []*gitlab.Project{project})
+	require.NoError(t, err)
+
+	require.Len(t, tokens, 2)
+	assert.Equal(t, int64(30), tokens[0].ID)
+	assert.Equal(t, "production/main", tokens[0].Name)
+	assert.Equal(t, dto.TypeAgentToken, tokens[0].Type)
+	assert.Equal(t, "group/project", tokens[0].Source)
+	assert.Equal(t, int64(1), tokens[0].SourceID)
+	assert.Equal(t, "", tokens[0].ExpiresAt)
+	assert.Equal(t, "2023-05-01", tokens[0].CreatedAt)
+	assert.Equal(t, "2024-11-15", tokens[0].LastUsedAt)
+	assert.Equal(t, "bob", tokens[0].Owner)
+	assert.Equal(t, []dto.Contact{{Username: "alice"}}, tokens[0].Contacts)
+	assert.Equal(t, "https://gitlab.example.com/group/project/-/cluster_agents/production", tokens[0].WebURL)
+	assert.False(t, tokens[0].Revoked)
+	assert.True(t, tokens[1].Revoked)
+}
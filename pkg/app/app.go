@@ -3,166 +3,426 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log/slog"
 	"net/http"
-	"os"
+	"strings"
+	"time"
 
 	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
 	"github.com/sgaunet/gitlab-token-expiration/pkg/logger"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/transport"
 	"github.com/sgaunet/gitlab-token-expiration/pkg/views"
 	"gitlab.com/gitlab-org/api/client-go"
 )
 
+// Paths of the settings pages of the tokens.
+const (
+	accessTokensSettingsPath         = "/-/settings/access_tokens"
+	deployTokensSettingsPath         = "/-/settings/repository#js-deploy-tokens"
+	personalAccessTokensSettingsPath = "/-/user_settings/personal_access_tokens"
+	pipelineTriggersSettingsPath     = "/-/settings/ci_cd#js-pipeline-triggers"
+)
+
 // App represents the application with GitLab client and configuration.
 type App struct {
-	gitlabClient  *gitlab.Client
-	printRevoked  bool
-	log           logger.Logger
-	view          views.Renderer
+	config              Config
+	gitlabClient        *gitlab.Client
+	clientErr           error
+	printRevoked        bool
+	resolveOwners       bool
+	oauthApplications   bool
+	listRunners         bool
+	listAgents          bool
+	listServiceAccounts bool
+	inventory           bool
+	api                 string
+	archived            string
+	sharedProjects      bool
+	skipForks           bool
+	excludedPaths       []string
+	owners              *ownerCache
+	progress            Progress
+	logTransport        *transport.LoggingTransport // nil if the requests are sent with Config.HTTPClient
+	httpClient          *http.Client
+	log                 logger.Logger
+	view                views.Renderer
 }
 
 // Option is a function that configures the App.
 type Option func(*App)
 
-// WithGitlabEndpoint sets the gitlab endpoint.
-func WithGitlabEndpoint(gitlabAPIEndpoint string) Option {
+// Progress receives the progress of a scan.
+type Progress interface {
+	// SourceScanned is called when a group or project has been scanned, with the number of
+	// tokens found and the error if some of its tokens could not be retrieved.
+	SourceScanned(kind string, tokens int, err error)
+}
+
+// noProgress is the Progress used when the progress of the scans is not reported.
+type noProgress struct{}
+
+func (noProgress) SourceScanned(string, int, error) {}
+
+// WithProgress reports the progress of the scans of groups and projects to p.
+func WithProgress(p Progress) Option {
 	return func(a *App) {
-		// Create new client with custom base URL
-		token := os.Getenv("GITLAB_TOKEN")
-		client, err := gitlab.NewClient(token, gitlab.WithBaseURL(gitlabAPIEndpoint))
-		if err == nil {
-			a.gitlabClient = client
-		}
+		a.progress = p
 	}
 }
 
-// WithRevokedToken sets the printRevoked flag.
-func WithRevokedToken(printRevoked bool) Option {
+// WithGitlabEndpoint sets the URL of the GitLab instance, like Config.BaseURL.
+func WithGitlabEndpoint(gitlabAPIEndpoint string) Option {
 	return func(a *App) {
-		a.printRevoked = printRevoked
+		a.config.BaseURL = gitlabAPIEndpoint
 	}
 }
 
-// NewApp returns a new App struct.
-func NewApp(v views.Renderer, opts ...Option) *App {
-	token := os.Getenv("GITLAB_TOKEN")
-	gitlabURI := os.Getenv("GITLAB_URI")
+// WithMaxRPS limits the number of requests per second sent to the GitLab API, like Config.MaxRPS.
+// 0 disables the limit.
+func WithMaxRPS(maxRPS float64) Option {
+	return func(a *App) {
+		a.config.MaxRPS = maxRPS
+	}
+}
 
-	var client *gitlab.Client
-	var err error
+// WithCache saves the responses of the GitLab API in dir, and uses them during ttl before
+// revalidating them with their ETag, like Config.CacheDir and Config.CacheTTL.
+func WithCache(dir string, ttl time.Duration) Option {
+	return func(a *App) {
+		a.config.CacheDir = dir
+		a.config.CacheTTL = ttl
+	}
+}
 
-	// If GITLAB_URI is set, use it as the base URL
-	if gitlabURI != "" {
-		client, err = gitlab.NewClient(token, gitlab.WithBaseURL(gitlabURI))
-	} else {
-		client, err = gitlab.NewClient(token)
+// WithHTTPTransport sets the transport sending the requests to GitLab, like Config.Transport,
+// e.g. with custom TLS or proxy settings built by transport.NewHTTPTransport. The requests still
+// go through the cache, retry and logging transports.
+func WithHTTPTransport(base http.RoundTripper) Option {
+	return func(a *App) {
+		a.config.Transport = base
 	}
+}
 
-	if err != nil {
-		// Handle error or use a fallback
-		client = nil
+// WithToken sets the GitLab token, like Config.Token.
+func WithToken(token string) Option {
+	return func(a *App) {
+		a.config.Token = token
 	}
+}
 
-	app := &App{
-		gitlabClient: client,
-		view:         v,
-		log:          slog.New(slog.DiscardHandler),
+// WithRevokedToken sets the printRevoked flag.
+func WithRevokedToken(printRevoked bool) Option {
+	return func(a *App) {
+		a.printRevoked = printRevoked
 	}
+}
+
+// NewApp returns a new App struct, configured with the GITLAB_URI and GITLAB_TOKEN environment
+// variables (see ConfigFromEnv), then with the options.
+// The error of the creation of the GitLab client is returned by Err: use New to validate the
+// configuration and get the error directly.
+func NewApp(v views.Renderer, opts ...Option) *App {
+	app := newApp(v, ConfigFromEnv())
 	for _, opt := range opts {
 		opt(app)
 	}
+	app.clientErr = app.connect()
 	return app
 }
 
+// WithLogger sets the logger, like Config.Logger. Every request sent to the GitLab API is logged at debug level.
+func WithLogger(l logger.Logger) Option {
+	return func(a *App) {
+		a.SetLogger(l)
+	}
+}
+
 // SetLogger sets the logger.
 func (a *App) SetLogger(l logger.Logger) {
+	a.config.Logger = l
 	a.log = l
+	if a.logTransport != nil {
+		a.logTransport.SetLogger(l)
+	}
 }
 
-// SetGitlabEndpoint sets the gitlab endpoint.
+// SetGitlabEndpoint sets the gitlab endpoint. The other settings of the connection are kept.
 func (a *App) SetGitlabEndpoint(gitlabAPIEndpoint string) {
-	// Create new client with custom base URL
-	token := os.Getenv("GITLAB_TOKEN")
-	client, err := gitlab.NewClient(token, gitlab.WithBaseURL(gitlabAPIEndpoint))
-	if err == nil {
-		a.gitlabClient = client
-	}
+	a.config.BaseURL = gitlabAPIEndpoint
+	a.clientErr = a.connect()
+}
+
+// Err returns the error of the creation of the GitLab client, e.g. an invalid GitLab URL.
+// The methods calling the GitLab API return an error wrapping ErrNoClient and this error
+// if there is no GitLab client.
+func (a *App) Err() error {
+	return a.clientErr
 }
 
-// SetToken sets the gitlab token.
+// SetToken sets the gitlab token. The other settings of the connection are kept.
 func (a *App) SetToken(token string) {
-	// Create new client with the provided token
-	client, err := gitlab.NewClient(token)
-	if err == nil {
-		a.gitlabClient = client
-	}
+	a.config.Token = token
+	a.clientErr = a.connect()
 }
 
-// SetHTTPClient sets the http client.
+// SetHTTPClient sets the http client, like Config.HTTPClient. The other settings of the connection are kept.
+// The requests are sent with httpClient as is, without the cache, retry and logging transports:
+// use WithHTTPTransport to only change the TLS or proxy settings.
 func (a *App) SetHTTPClient(httpClient *http.Client) {
-	// Create new client with custom HTTP client
-	token := os.Getenv("GITLAB_TOKEN")
-	client, err := gitlab.NewClient(token, gitlab.WithHTTPClient(httpClient))
-	if err == nil {
-		a.gitlabClient = client
+	a.config.HTTPClient = httpClient
+	a.clientErr = a.connect()
+}
+
+// BaseURL returns the URL of the GitLab API used by the application, or an empty string if the
+// GitLab client could not be created (see Err).
+func (a *App) BaseURL() string {
+	if a.gitlabClient == nil {
+		return ""
+	}
+	return a.gitlabClient.BaseURL().String()
+}
+
+// checkClient returns an error wrapping ErrNoClient and the reason if the GitLab client could not be
+// created. The methods calling the GitLab API check it first.
+func (a *App) checkClient() error {
+	if a.gitlabClient != nil {
+		return nil
+	}
+	if a.clientErr != nil {
+		return fmt.Errorf("%w: %w", ErrNoClient, a.clientErr)
 	}
+	return ErrNoClient
+}
+
+// webURL returns the URL of the GitLab web interface.
+func (a *App) webURL() string {
+	return strings.TrimSuffix(strings.TrimSuffix(a.BaseURL(), "/"), "/api/v4")
 }
 
 // GetTokensOfProjects returns the tokens of multiple projects.
-func (a *App) GetTokensOfProjects(_ context.Context, projects []*gitlab.Project) ([]dto.Token, error) {
+// If the tokens of some projects cannot be retrieved, the other tokens are returned
+// along with a *ScanError listing the skipped projects.
+func (a *App) GetTokensOfProjects(ctx context.Context, projects []*gitlab.Project) ([]dto.Token, error) {
+	if err := a.checkClient(); err != nil {
+		return nil, err
+	}
 	var tokens []dto.Token
+	scanErr := &ScanError{}
 
 	for _, project := range projects {
-		projectAccessTokens, _, err := a.gitlabClient.ProjectAccessTokens.ListProjectAccessTokens(project.ID, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to list project access tokens for project %d: %w", project.ID, err)
+		a.log.Debug("scanning project", "project", project.PathWithNamespace, "id", project.ID)
+		var members []member
+		if a.resolveOwners {
+			var err error
+			members, err = a.projectMembers(project.ID)
+			if err != nil {
+				// The tokens are still listed, without their owners: the project is not skipped
+				a.log.Warn("owners of the tokens of project not resolved", "project", project.PathWithNamespace, "error", err)
+			}
+		}
+
+		collectors := []func() ([]dto.Token, error){
+			func() ([]dto.Token, error) { return a.getAccessTokensOfProject(project, members) },
+			func() ([]dto.Token, error) { return a.getPipelineTriggersOfProject(project) },
+			func() ([]dto.Token, error) { return a.getDeployKeysOfProject(project) },
+		}
+		if a.inventory {
+			// Get hooks and integrations of the project
+			collectors = append(collectors, func() ([]dto.Token, error) {
+				return a.getCredentialsInventoryOfProject(project)
+			})
 		}
-		dtoTokens := ConvertProjectAccessTokenToDTOTokens(projectAccessTokens)
+		if a.listAgents {
+			// Get tokens of the agents for Kubernetes of the project
+			collectors = append(collectors, func() ([]dto.Token, error) {
+				return a.getAgentTokensOfProject(project)
+			})
+		}
+		found := 0
+		var errs []error
+		for _, collect := range collectors {
+			dtoTokens, err := collect()
+			if err != nil {
+				a.log.Info("tokens of project skipped", "project", project.PathWithNamespace, "error", err)
+				errs = append(errs, err)
+				continue
+			}
+			if a.resolveOwners {
+				setResourceOwners(dtoTokens, nil, members)
+			}
+			tokens = append(tokens, dtoTokens...)
+			found += len(dtoTokens)
+		}
+		// The project is skipped once, with the reasons of all the failing collectors
+		sourceErr := errors.Join(errs...)
+		if sourceErr != nil {
+			scanErr.skip(dto.SourceKindProject, project.ID, project.PathWithNamespace, sourceErr)
+		}
+		a.progress.SourceScanned(dto.SourceKindProject, found, sourceErr)
+	}
+
+	if a.listRunners {
+		runners, err := a.GetRunnersOfProjects(ctx, projects)
+		tokens = append(tokens, runners...)
+		scanErr.merge(err)
+	}
+	return tokens, scanErr.errOrNil()
+}
 
-		// Add the source
-		for i := range dtoTokens {
-			dtoTokens[i].Source = project.PathWithNamespace
+// getAccessTokensOfProject returns the access tokens of a project.
+func (a *App) getAccessTokensOfProject(project *gitlab.Project, members []member) ([]dto.Token, error) {
+	projectAccessTokens, _, err := a.gitlabClient.ProjectAccessTokens.ListProjectAccessTokens(project.ID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list project access tokens for project %d: %w", project.ID, err)
+	}
+	dtoTokens := ConvertProjectAccessTokenToDTOTokens(projectAccessTokens)
+
+	// Add the source
+	for i := range dtoTokens {
+		dtoTokens[i].Source = project.PathWithNamespace
+		dtoTokens[i].SourceID = project.ID
+		dtoTokens[i].WebURL = project.WebURL + accessTokensSettingsPath
+	}
+	if a.resolveOwners {
+		botUserIDs := make([]int64, 0, len(projectAccessTokens))
+		for _, t := range projectAccessTokens {
+			botUserIDs = append(botUserIDs, t.UserID)
 		}
-		tokens = append(tokens, dtoTokens...)
+		setResourceOwners(dtoTokens, botUserIDs, members)
+	}
+	return dtoTokens, nil
+}
+
+// getPipelineTriggersOfProject returns the pipeline trigger tokens of a project.
+func (a *App) getPipelineTriggersOfProject(project *gitlab.Project) ([]dto.Token, error) {
+	pipelineTriggers, err := a.listPipelineTriggers(project.ID)
+	if err != nil {
+		return nil, err
 	}
-	return tokens, nil
+	dtoTokens := ConvertPipelineTriggerToDTOTokens(pipelineTriggers)
+	// Add the source
+	for i := range dtoTokens {
+		dtoTokens[i].Source = project.PathWithNamespace
+		dtoTokens[i].SourceID = project.ID
+		dtoTokens[i].WebURL = project.WebURL + pipelineTriggersSettingsPath
+	}
+	return dtoTokens, nil
 }
 
 // GetTokensOfGroups returns the tokens of all groups.
-func (a *App) GetTokensOfGroups(_ context.Context, groups []*gitlab.Group) ([]dto.Token, error) {
+// If the tokens of some groups cannot be retrieved, the other tokens are returned
+// along with a *ScanError listing the skipped groups.
+func (a *App) GetTokensOfGroups(ctx context.Context, groups []*gitlab.Group) ([]dto.Token, error) {
+	if err := a.checkClient(); err != nil {
+		return nil, err
+	}
 	var tokens []dto.Token
+	scanErr := &ScanError{}
 
 	for _, group := range groups {
-		// Get access tokens of the group
-		groupAccessTokens, _, err := a.gitlabClient.GroupAccessTokens.ListGroupAccessTokens(group.ID, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to list group access tokens for group %d: %w", group.ID, err)
+		a.log.Debug("scanning group", "group", group.Path, "id", group.ID)
+		var members []member
+		if a.resolveOwners {
+			var err error
+			members, err = a.groupMembers(group.ID)
+			if err != nil {
+				// The tokens are still listed, without their owners: the group is not skipped
+				a.log.Warn("owners of the tokens of group not resolved", "group", group.Path, "error", err)
+			}
 		}
-		dtoTokens := ConvertGroupAccessTokenToDTOTokens(groupAccessTokens)
-		// Add the source
-		for i := range dtoTokens {
-			dtoTokens[i].Source = group.Path
-		}
-		tokens = append(tokens, dtoTokens...)
 
-		// Get deploy tokens of the group
-		groupDeployTokens, _, err := a.gitlabClient.DeployTokens.ListGroupDeployTokens(group.ID, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to list group deploy tokens for group %d: %w", group.ID, err)
+		collectors := []func() ([]dto.Token, error){
+			func() ([]dto.Token, error) { return a.getAccessTokensOfGroup(group, members) },
+			func() ([]dto.Token, error) { return a.getDeployTokensOfGroup(group) },
+		}
+		if a.inventory {
+			// Get hooks and integrations of the group
+			collectors = append(collectors, func() ([]dto.Token, error) {
+				return a.getCredentialsInventoryOfGroup(group)
+			})
+		}
+		if a.listServiceAccounts && group.ParentID == 0 {
+			// Get personal access tokens of the service accounts of the top-level group
+			collectors = append(collectors, func() ([]dto.Token, error) {
+				return a.getServiceAccountTokensOfGroup(group)
+			})
 		}
-		dtoTokens = ConvertGroupDeployTokenToDTOTokens(groupDeployTokens)
-		// Add the source
-		for i := range dtoTokens {
-			dtoTokens[i].Source = group.Path
+		found := 0
+		var errs []error
+		for _, collect := range collectors {
+			dtoTokens, err := collect()
+			if err != nil {
+				a.log.Info("tokens of group skipped", "group", group.Path, "error", err)
+				errs = append(errs, err)
+				continue
+			}
+			if a.resolveOwners {
+				setResourceOwners(dtoTokens, nil, members)
+			}
+			tokens = append(tokens, dtoTokens...)
+			found += len(dtoTokens)
+		}
+		// The group is skipped once, with the reasons of all the failing collectors
+		sourceErr := errors.Join(errs...)
+		if sourceErr != nil {
+			scanErr.skip(dto.SourceKindGroup, group.ID, group.Path, sourceErr)
+		}
+		a.progress.SourceScanned(dto.SourceKindGroup, found, sourceErr)
+	}
+
+	if a.listRunners {
+		runners, err := a.GetRunnersOfGroups(ctx, groups)
+		tokens = append(tokens, runners...)
+		scanErr.merge(err)
+	}
+	return tokens, scanErr.errOrNil()
+}
+
+// getAccessTokensOfGroup returns the access tokens of a group.
+func (a *App) getAccessTokensOfGroup(group *gitlab.Group, members []member) ([]dto.Token, error) {
+	groupAccessTokens, _, err := a.gitlabClient.GroupAccessTokens.ListGroupAccessTokens(group.ID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list group access tokens for group %d: %w", group.ID, err)
+	}
+	dtoTokens := ConvertGroupAccessTokenToDTOTokens(groupAccessTokens)
+	// Add the source
+	for i := range dtoTokens {
+		dtoTokens[i].Source = group.Path
+		dtoTokens[i].SourceID = group.ID
+		dtoTokens[i].WebURL = group.WebURL + accessTokensSettingsPath
+	}
+	if a.resolveOwners {
+		botUserIDs := make([]int64, 0, len(groupAccessTokens))
+		for _, t := range groupAccessTokens {
+			botUserIDs = append(botUserIDs, t.UserID)
 		}
-		tokens = append(tokens, dtoTokens...)
+		setResourceOwners(dtoTokens, botUserIDs, members)
 	}
-	return tokens, nil
+	return dtoTokens, nil
+}
+
+// getDeployTokensOfGroup returns the deploy tokens of a group.
+func (a *App) getDeployTokensOfGroup(group *gitlab.Group) ([]dto.Token, error) {
+	groupDeployTokens, _, err := a.gitlabClient.DeployTokens.ListGroupDeployTokens(group.ID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list group deploy tokens for group %d: %w", group.ID, err)
+	}
+	dtoTokens := ConvertGroupDeployTokenToDTOTokens(groupDeployTokens)
+	// Add the source
+	for i := range dtoTokens {
+		dtoTokens[i].Source = group.Path
+		dtoTokens[i].SourceID = group.ID
+		dtoTokens[i].WebURL = group.WebURL + deployTokensSettingsPath
+	}
+	return dtoTokens, nil
 }
 
 // GetProject returns the project that matches the given ID.
 func (a *App) GetProject(projectID int64) (*gitlab.Project, error) {
+	if err := a.checkClient(); err != nil {
+		return nil, err
+	}
 	project, _, err := a.gitlabClient.Projects.GetProject(projectID, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get project %d: %w", projectID, err)
@@ -172,6 +432,9 @@ func (a *App) GetProject(projectID int64) (*gitlab.Project, error) {
 
 // GetGroup returns the group that matches the given ID.
 func (a *App) GetGroup(groupID int64) (*gitlab.Group, error) {
+	if err := a.checkClient(); err != nil {
+		return nil, err
+	}
 	group, _, err := a.gitlabClient.Groups.GetGroup(groupID, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get group %d: %w", groupID, err)
@@ -181,6 +444,9 @@ func (a *App) GetGroup(groupID int64) (*gitlab.Group, error) {
 
 // GetSubGroups returns the subgroups of the group that matches the given ID.
 func (a *App) GetSubGroups(groupID int64) ([]*gitlab.Group, error) {
+	if err := a.checkClient(); err != nil {
+		return nil, err
+	}
 	groups, _, err := a.gitlabClient.Groups.ListSubGroups(groupID, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list subgroups for group %d: %w", groupID, err)
@@ -188,39 +454,23 @@ func (a *App) GetSubGroups(groupID int64) ([]*gitlab.Group, error) {
 	return groups, nil
 }
 
-// GetRecursiveProjectsOfGroup returns the projects of the group that matches the given ID.
-func (a *App) GetRecursiveProjectsOfGroup(groupID int64) ([]*gitlab.Project, error) {
-	// Get projects of the group
-	projects, _, err := a.gitlabClient.Groups.ListGroupProjects(groupID, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list projects for group %d: %w", groupID, err)
-	}
-
-	// Get subgroups recursively
-	subgroups, err := a.GetSubGroups(groupID)
-	if err != nil {
-		return projects, err // Return what we have so far
-	}
-
-	// Get projects from each subgroup recursively
-	for _, subgroup := range subgroups {
-		subProjects, err := a.GetRecursiveProjectsOfGroup(subgroup.ID)
-		if err != nil {
-			continue // Skip this subgroup on error
-		}
-		projects = append(projects, subProjects...)
-	}
-
-	return projects, nil
-}
-
 // GetPersonalAccessTokens returns the personal access tokens.
 func (a *App) GetPersonalAccessTokens(_ context.Context) ([]dto.Token, error) {
+	if err := a.checkClient(); err != nil {
+		return nil, err
+	}
 	tokens, _, err := a.gitlabClient.PersonalAccessTokens.ListPersonalAccessTokens(nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list personal access tokens: %w", err)
 	}
 
 	res := ConvertPersonalGitlabTokenToDTOTokens(tokens)
+	for i := range res {
+		res[i].SourceID = tokens[i].UserID
+		res[i].WebURL = a.webURL() + personalAccessTokensSettingsPath
+	}
+	if a.resolveOwners {
+		a.setPersonalOwners(res, tokens)
+	}
 	return res, nil
 }
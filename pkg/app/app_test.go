@@ -106,4 +106,11 @@ func TestApp_GetTokensOfGroups_ErrorHandling(t *testing.T) {
 func TestApp_GetPersonalAccessTokens_ErrorHandling(t *testing.T) {
 	// This test demonstrates the API but would need proper mocking to work
 	t.Skip("Requires GitLab client mocking or integration test setup")
-}
\ No newline at end of file
+}
+func TestApp_SetHTTPClient_KeepsEndpoint(t *testing.T) {
+	application := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint("https://gitlab.example.com"))
+
+	application.SetHTTPClient(&http.Client{})
+
+	assert.Equal(t, "https://gitlab.example.com/api/v4/", application.BaseURL())
+}
@@ -0,0 +1,146 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/logger"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/transport"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/views"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// ErrInvalidConfig is returned by New when the configuration is invalid.
+var ErrInvalidConfig = errors.New("invalid configuration")
+
+// ErrNoClient is returned by the methods calling the GitLab API when the GitLab client
+// could not be created, e.g. because of an invalid GitLab URL given to NewApp.
+var ErrNoClient = errors.New("no GitLab client")
+
+// Config is the configuration of the connection to the GitLab API.
+//
+// The requests are sent through the cache (if CacheDir is set), the rate-limit aware retry
+// transport, the logging transport, then Transport. HTTPClient replaces this whole chain.
+type Config struct {
+	// BaseURL is the URL of the GitLab instance, https://gitlab.com if empty.
+	BaseURL string
+	// Token is the token used to call the API, the requests are not authenticated if empty.
+	Token string
+	// UserAgent is the User-Agent header of the requests, the one of the GitLab client if empty.
+	UserAgent string
+	// Timeout is the timeout of a request, retries included. 0 for no timeout.
+	Timeout time.Duration
+	// MaxRPS is the maximum number of requests per second. 0 for no limit.
+	MaxRPS float64
+	// CacheDir is the directory where the responses are cached, no cache if empty.
+	CacheDir string
+	// CacheTTL is the duration during which a cached response is used without revalidation.
+	CacheTTL time.Duration
+	// Transport sends the requests, e.g. with the TLS or proxy settings of transport.NewHTTPTransport.
+	// http.DefaultTransport if nil.
+	Transport http.RoundTripper
+	// HTTPClient sends the requests as is, instead of the chain of transports.
+	// It cannot be combined with Timeout, MaxRPS, CacheDir and Transport.
+	HTTPClient *http.Client
+	// Logger logs the requests at debug level, no log if nil.
+	Logger logger.Logger
+}
+
+// ConfigFromEnv returns the configuration given by the GITLAB_URI and GITLAB_TOKEN environment variables.
+func ConfigFromEnv() Config {
+	return Config{
+		BaseURL: os.Getenv("GITLAB_URI"),
+		Token:   os.Getenv("GITLAB_TOKEN"),
+	}
+}
+
+// validate returns an error if the configuration is invalid.
+func (c Config) validate() error {
+	if c.BaseURL != "" {
+		u, err := url.Parse(c.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: invalid GitLab URL %q, it must start with https://", ErrInvalidConfig, c.BaseURL)
+		}
+	}
+	if c.Timeout < 0 || c.MaxRPS < 0 || c.CacheTTL < 0 {
+		return fmt.Errorf("%w: the timeout, maximum number of requests per second and cache TTL must not be negative",
+			ErrInvalidConfig)
+	}
+	if c.HTTPClient != nil && (c.Timeout != 0 || c.MaxRPS != 0 || c.CacheDir != "" || c.Transport != nil) {
+		return fmt.Errorf("%w: the HTTP client cannot be combined with a timeout, a rate limit, a cache or a transport",
+			ErrInvalidConfig)
+	}
+	return nil
+}
+
+// New returns the App connected to GitLab with the configuration, then configured with the options.
+// The options setting the connection (e.g. WithToken) override the configuration.
+// It returns an error if the configuration is invalid.
+func New(v views.Renderer, config Config, opts ...Option) (*App, error) {
+	app := newApp(v, config)
+	for _, opt := range opts {
+		opt(app)
+	}
+	if err := app.config.validate(); err != nil {
+		return nil, err
+	}
+	if err := app.connect(); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// newApp returns the App with the configuration, not connected yet.
+func newApp(v views.Renderer, config Config) *App {
+	var log logger.Logger = slog.New(slog.DiscardHandler)
+	if config.Logger != nil {
+		log = config.Logger
+	}
+	return &App{
+		config:   config,
+		view:     v,
+		owners:   newOwnerCache(),
+		progress: noProgress{},
+		log:      log,
+	}
+}
+
+// connect creates the HTTP client and the GitLab client of the configuration. The retries of
+// the GitLab client are disabled since the retry transport retries the requests.
+// On error, the clients are cleared, so that the previous connection is not used with another
+// configuration: the methods calling the GitLab API then return ErrNoClient.
+func (a *App) connect() error {
+	httpClient := a.config.HTTPClient
+	var logTransport *transport.LoggingTransport
+	if httpClient == nil {
+		logTransport = transport.NewLoggingTransport(a.config.Transport, a.log)
+		var rt http.RoundTripper = transport.NewRetryTransport(logTransport, transport.WithMaxRPS(a.config.MaxRPS))
+		if a.config.CacheDir != "" {
+			rt = transport.NewCacheTransport(rt, a.config.CacheDir, a.config.CacheTTL)
+		}
+		httpClient = &http.Client{Transport: rt, Timeout: a.config.Timeout}
+	}
+
+	opts := []gitlab.ClientOptionFunc{
+		gitlab.WithHTTPClient(httpClient),
+		gitlab.WithoutRetries(),
+	}
+	if a.config.BaseURL != "" {
+		opts = append(opts, gitlab.WithBaseURL(a.config.BaseURL))
+	}
+	if a.config.UserAgent != "" {
+		opts = append(opts, gitlab.WithUserAgent(a.config.UserAgent))
+	}
+	client, err := gitlab.NewClient(a.config.Token, opts...)
+	if err != nil {
+		a.gitlabClient, a.httpClient, a.logTransport = nil, nil, nil
+		return fmt.Errorf("failed to create GitLab client: %w", err)
+	}
+	a.gitlabClient, a.httpClient, a.logTransport = client, httpClient, logTransport
+	return nil
+}
@@ -0,0 +1,157 @@
+package app_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/app"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// headerServer records the token and user agent of the requests.
+type headerServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	tokens    []string
+	userAgent string
+}
+
+func newHeaderServer(t *testing.T) *headerServer {
+	t.Helper()
+	s := &headerServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.tokens = append(s.tokens, r.Header.Get("Private-Token"))
+		s.userAgent = r.Header.Get("User-Agent")
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": 1, "name": "group"}`))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config app.Config
+	}{
+		{name: "URL without scheme", config: app.Config{BaseURL: "gitlab.example.com"}},
+		{name: "URL with another scheme", config: app.Config{BaseURL: "ftp://gitlab.example.com"}},
+		{name: "negative timeout", config: app.Config{Timeout: -time.Second}},
+		{name: "negative max RPS", config: app.Config{MaxRPS: -1}},
+		{name: "HTTP client with timeout", config: app.Config{HTTPClient: &http.Client{}, Timeout: time.Second}},
+		{
+			name:   "HTTP client with transport",
+			config: app.Config{HTTPClient: &http.Client{}, Transport: http.DefaultTransport},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := app.New(&MockRenderer{}, tt.config)
+			require.ErrorIs(t, err, app.ErrInvalidConfig)
+		})
+	}
+}
+
+func TestNew_Config(t *testing.T) {
+	server := newHeaderServer(t)
+
+	a, err := app.New(&MockRenderer{}, app.Config{BaseURL: server.URL, Token: "glpat-config", UserAgent: "test-agent/1.0"})
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/api/v4/", a.BaseURL())
+
+	_, err = a.GetGroup(1)
+	require.NoError(t, err)
+	assert.Equal(t, "glpat-config", server.tokens[0])
+	assert.Equal(t, "test-agent/1.0", server.userAgent)
+}
+
+func TestNew_OptionsOverrideConfig(t *testing.T) {
+	server := newHeaderServer(t)
+
+	a, err := app.New(&MockRenderer{}, app.Config{BaseURL: "https://gitlab.example.com", Token: "glpat-config"},
+		app.WithGitlabEndpoint(server.URL), app.WithToken("glpat-option"))
+	require.NoError(t, err)
+
+	_, err = a.GetGroup(1)
+	require.NoError(t, err)
+	assert.Equal(t, "glpat-option", server.tokens[0])
+}
+
+func TestApp_SettersKeepConfig(t *testing.T) {
+	server := newHeaderServer(t)
+	a, err := app.New(&MockRenderer{}, app.Config{BaseURL: server.URL, Token: "glpat-first", UserAgent: "test-agent/1.0"})
+	require.NoError(t, err)
+
+	a.SetToken("glpat-second")
+	require.NoError(t, a.Err())
+	assert.Equal(t, server.URL+"/api/v4/", a.BaseURL(), "SetToken keeps the URL")
+
+	a.SetHTTPClient(&http.Client{})
+	require.NoError(t, a.Err())
+	assert.Equal(t, server.URL+"/api/v4/", a.BaseURL(), "SetHTTPClient keeps the URL")
+
+	_, err = a.GetGroup(1)
+	require.NoError(t, err)
+	assert.Equal(t, "glpat-second", server.tokens[0], "SetHTTPClient keeps the token")
+	assert.Equal(t, "test-agent/1.0", server.userAgent)
+}
+
+func TestNew_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	a, err := app.New(&MockRenderer{}, app.Config{BaseURL: server.URL, Timeout: 50 * time.Millisecond})
+	require.NoError(t, err)
+
+	_, err = a.GetGroup(1)
+	require.Error(t, err)
+}
+
+func TestNewApp_NoClient(t *testing.T) {
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(":bad"))
+	require.Error(t, a.Err())
+
+	assert.Empty(t, a.BaseURL())
+	_, err := a.GetGroup(1)
+	require.ErrorIs(t, err, app.ErrNoClient)
+	assert.ErrorIs(t, err, a.Err())
+	_, err = a.GetTokensOfGroups(t.Context(), nil)
+	assert.ErrorIs(t, err, app.ErrNoClient)
+	_, err = a.GetUserCredentials(t.Context(), 0)
+	assert.ErrorIs(t, err, app.ErrNoClient)
+	err = a.RevokeToken(t.Context(), dto.Token{Type: dto.TypePersonalAccessToken})
+	assert.ErrorIs(t, err, app.ErrNoClient)
+}
+
+func TestApp_SetGitlabEndpoint_Invalid(t *testing.T) {
+	server := newHeaderServer(t)
+	a, err := app.New(&MockRenderer{}, app.Config{BaseURL: server.URL, Token: "glpat-test"})
+	require.NoError(t, err)
+
+	a.SetGitlabEndpoint(":bad")
+	require.Error(t, a.Err())
+	assert.Empty(t, a.BaseURL(), "the previous client is not kept")
+	_, err = a.GetGroup(1)
+	require.ErrorIs(t, err, app.ErrNoClient)
+	assert.Empty(t, server.tokens, "no request sent to the previous URL")
+
+	a.SetGitlabEndpoint(server.URL)
+	require.NoError(t, a.Err())
+	_, err = a.GetGroup(1)
+	assert.NoError(t, err)
+}
@@ -1,6 +1,10 @@
 package app
 
 import (
+	"fmt"
+	"net/url"
+	"time"
+
 	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
 	"gitlab.com/gitlab-org/api/client-go"
 )
@@ -18,12 +22,15 @@ func ConvertGroupAccessTokenToDTOToken(groupAccessToken *gitlab.GroupAccessToken
 	}
 
 	return dto.Token{
-		ID:        groupAccessToken.ID,
-		Name:      groupAccessToken.Name,
-		ExpiresAt: expiresAt,
-		Revoked:   groupAccessToken.Revoked,
-		Source:    "group",
-		Type:      "access_token",
+		ID:         groupAccessToken.ID,
+		Name:       groupAccessToken.Name,
+		ExpiresAt:  expiresAt,
+		Revoked:    groupAccessToken.Revoked,
+		Scopes:     groupAccessToken.Scopes,
+		LastUsedAt: formatDate(groupAccessToken.LastUsedAt),
+		Source:     "group",
+		SourceKind: dto.SourceKindGroup,
+		Type:       dto.TypeAccessToken,
 	}
 }
 
@@ -36,12 +43,14 @@ func ConvertGroupDeployTokenToDTOToken(groupDeployToken *gitlab.DeployToken) dto
 	}
 
 	return dto.Token{
-		ID:        groupDeployToken.ID,
-		Name:      groupDeployToken.Name,
-		ExpiresAt: expiresAt,
-		Revoked:   groupDeployToken.Revoked,
-		Source:    "group",
-		Type:      "deploy_token",
+		ID:         groupDeployToken.ID,
+		Name:       groupDeployToken.Name,
+		ExpiresAt:  expiresAt,
+		Revoked:    groupDeployToken.Revoked,
+		Scopes:     groupDeployToken.Scopes,
+		Source:     "group",
+		SourceKind: dto.SourceKindGroup,
+		Type:       dto.TypeDeployToken,
 	}
 }
 
@@ -58,12 +67,15 @@ func ConvertProjectAccessTokenToDTOToken(projectAccessToken *gitlab.ProjectAcces
 	}
 
 	return dto.Token{
-		ID:        projectAccessToken.ID,
-		Name:      projectAccessToken.Name,
-		ExpiresAt: expiresAt,
-		Revoked:   projectAccessToken.Revoked,
-		Source:    "project",
-		Type:      "access_token",
+		ID:         projectAccessToken.ID,
+		Name:       projectAccessToken.Name,
+		ExpiresAt:  expiresAt,
+		Revoked:    projectAccessToken.Revoked,
+		Scopes:     projectAccessToken.Scopes,
+		LastUsedAt: formatDate(projectAccessToken.LastUsedAt),
+		Source:     "project",
+		SourceKind: dto.SourceKindProject,
+		Type:       dto.TypeAccessToken,
 	}
 }
 
@@ -76,12 +88,14 @@ func ConvertProjectDeployTokenToDTOToken(projectDeployToken *gitlab.DeployToken)
 	}
 
 	return dto.Token{
-		ID:        projectDeployToken.ID,
-		Name:      projectDeployToken.Name,
-		ExpiresAt: expiresAt,
-		Revoked:   projectDeployToken.Revoked,
-		Source:    "project",
-		Type:      "deploy_token",
+		ID:         projectDeployToken.ID,
+		Name:       projectDeployToken.Name,
+		ExpiresAt:  expiresAt,
+		Revoked:    projectDeployToken.Revoked,
+		Scopes:     projectDeployToken.Scopes,
+		Source:     "project",
+		SourceKind: dto.SourceKindProject,
+		Type:       dto.TypeDeployToken,
 	}
 }
 
@@ -98,12 +112,212 @@ func ConvertPersonalGitlabTokenToDTOToken(personalGitlabToken *gitlab.PersonalAc
 	}
 
 	return dto.Token{
-		ID:        personalGitlabToken.ID,
-		Name:      personalGitlabToken.Name,
-		ExpiresAt: expiresAt,
-		Revoked:   personalGitlabToken.Revoked,
-		Source:    "",
-		Type:      "personal_access_token",
+		ID:         personalGitlabToken.ID,
+		Name:       personalGitlabToken.Name,
+		ExpiresAt:  expiresAt,
+		Revoked:    personalGitlabToken.Revoked,
+		Scopes:     personalGitlabToken.Scopes,
+		LastUsedAt: formatDate(personalGitlabToken.LastUsedAt),
+		Source:     "",
+		SourceKind: dto.SourceKindUser,
+		Type:       dto.TypePersonalAccessToken,
+	}
+}
+
+// ConvertPipelineTriggerToDTOToken converts a GitLab pipeline trigger token to a DTO token.
+func ConvertPipelineTriggerToDTOToken(pipelineTrigger *PipelineTrigger) dto.Token {
+	// Convert time format
+	var expiresAt string
+	if pipelineTrigger.ExpiresAt != nil {
+		expiresAt = pipelineTrigger.ExpiresAt.String()
+		const dateFormatLength = 10
+		if len(expiresAt) >= dateFormatLength {
+			expiresAt = expiresAt[:dateFormatLength] // Extract YYYY-MM-DD part
+		}
+	}
+	var owner string
+	if pipelineTrigger.Owner != nil {
+		owner = pipelineTrigger.Owner.Username
+	}
+
+	return dto.Token{
+		ID:         pipelineTrigger.ID,
+		Name:       pipelineTrigger.Description,
+		ExpiresAt:  expiresAt,
+		Revoked:    pipelineTrigger.DeletedAt != nil,
+		LastUsedAt: formatDate(pipelineTrigger.LastUsed),
+		Owner:      owner,
+		Source:     "project",
+		SourceKind: dto.SourceKindProject,
+		Type:       dto.TypePipelineTrigger,
+	}
+}
+
+// ConvertProjectDeployKeyToDTOToken converts a GitLab project deploy key to a DTO token.
+func ConvertProjectDeployKeyToDTOToken(projectDeployKey *gitlab.ProjectDeployKey) dto.Token {
+	return dto.Token{
+		ID:         projectDeployKey.ID,
+		Name:       projectDeployKey.Title,
+		ExpiresAt:  formatDate(projectDeployKey.ExpiresAt),
+		Source:     "project",
+		SourceKind: dto.SourceKindProject,
+		Type:       dto.TypeDeployKey,
+	}
+}
+
+// ConvertInstanceDeployKeyToDTOToken converts a GitLab instance deploy key to a DTO token.
+func ConvertInstanceDeployKeyToDTOToken(instanceDeployKey *gitlab.InstanceDeployKey) dto.Token {
+	return dto.Token{
+		ID:         instanceDeployKey.ID,
+		Name:       instanceDeployKey.Title,
+		ExpiresAt:  formatDate(instanceDeployKey.ExpiresAt),
+		Source:     "instance",
+		SourceKind: dto.SourceKindInstance,
+		Type:       dto.TypeDeployKey,
+	}
+}
+
+// ConvertSSHKeyToDTOToken converts a GitLab user SSH key to a DTO token.
+func ConvertSSHKeyToDTOToken(sshKey *gitlab.SSHKey) dto.Token {
+	return dto.Token{
+		ID:         sshKey.ID,
+		Name:       sshKey.Title,
+		ExpiresAt:  formatDate(sshKey.ExpiresAt),
+		Source:     "",
+		SourceKind: dto.SourceKindUser,
+		Type:       dto.TypeSSHKey,
+	}
+}
+
+// ConvertImpersonationTokenToDTOToken converts a GitLab impersonation token to a DTO token.
+func ConvertImpersonationTokenToDTOToken(impersonationToken *gitlab.ImpersonationToken) dto.Token {
+	// Convert time format
+	var expiresAt string
+	if impersonationToken.ExpiresAt != nil {
+		expiresAt = impersonationToken.ExpiresAt.String()
+		const dateFormatLength = 10
+		if len(expiresAt) >= dateFormatLength {
+			expiresAt = expiresAt[:dateFormatLength] // Extract YYYY-MM-DD part
+		}
+	}
+
+	return dto.Token{
+		ID:         impersonationToken.ID,
+		Name:       impersonationToken.Name,
+		ExpiresAt:  expiresAt,
+		Revoked:    impersonationToken.Revoked,
+		Scopes:     impersonationToken.Scopes,
+		LastUsedAt: formatDate(impersonationToken.LastUsedAt),
+		Source:     "",
+		SourceKind: dto.SourceKindUser,
+		Type:       dto.TypeImpersonationToken,
+	}
+}
+
+// ConvertGPGKeyToDTOToken converts a GitLab GPG key to a DTO token.
+// GitLab does not expose the expiration date of GPG keys.
+func ConvertGPGKeyToDTOToken(gpgKey *gitlab.GPGKey) dto.Token {
+	return dto.Token{
+		ID:         gpgKey.ID,
+		Name:       fmt.Sprintf("GPG key %d", gpgKey.ID),
+		Source:     "",
+		SourceKind: dto.SourceKindUser,
+		Type:       dto.TypeGPGKey,
+	}
+}
+
+// ConvertOAuthApplicationToDTOToken converts a GitLab OAuth application to a DTO token.
+// The secret of an OAuth application does not expire.
+func ConvertOAuthApplicationToDTOToken(application *gitlab.Application) dto.Token {
+	return dto.Token{
+		ID:         application.ID,
+		Name:       application.ApplicationName,
+		Source:     "instance",
+		SourceKind: dto.SourceKindInstance,
+		Type:       dto.TypeOAuthApplication,
+	}
+}
+
+// ConvertRunnerToDTOToken converts a GitLab runner to a DTO token representing its authentication token.
+func ConvertRunnerToDTOToken(runner *Runner) dto.Token {
+	name := runner.Description
+	if name == "" {
+		name = fmt.Sprintf("runner %d", runner.ID)
+	}
+	var owner string
+	if runner.CreatedBy != nil {
+		owner = runner.CreatedBy.Username
+	}
+
+	token := dto.Token{
+		ID:         runner.ID,
+		Name:       name,
+		ExpiresAt:  formatDate(runner.TokenExpiresAt),
+		CreatedAt:  formatDate(runner.CreatedAt),
+		LastUsedAt: formatDate(runner.ContactedAt),
+		Owner:      owner,
+		Type:       dto.TypeRunnerToken,
+	}
+	switch runner.RunnerType {
+	case groupRunnerType:
+		token.Source = "group"
+		token.SourceKind = dto.SourceKindGroup
+	case projectRunnerType:
+		token.Source = "project"
+		token.SourceKind = dto.SourceKindProject
+	default:
+		token.Source = "instance"
+		token.SourceKind = dto.SourceKindInstance
+	}
+	return token
+}
+
+// ConvertAgentTokenToDTOToken converts a token of a GitLab agent for Kubernetes to a DTO token.
+// Agent tokens do not expire.
+func ConvertAgentTokenToDTOToken(agent *gitlab.Agent, agentToken *gitlab.AgentToken) dto.Token {
+	return dto.Token{
+		ID:         agentToken.ID,
+		Name:       agent.Name + "/" + agentToken.Name,
+		Revoked:    agentToken.Status == agentTokenStatusRevoked,
+		CreatedAt:  formatDate(agentToken.CreatedAt),
+		LastUsedAt: formatDate(agentToken.LastUsedAt),
+		Source:     "project",
+		SourceKind: dto.SourceKindProject,
+		Type:       dto.TypeAgentToken,
+	}
+}
+
+// hookHost returns the scheme and host of the URL of a hook. The rest of the URL is dropped
+// because it may contain secrets.
+func hookHost(hookURL string) string {
+	u, err := url.Parse(hookURL)
+	if err != nil || u.Host == "" {
+		return "unknown host"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// ConvertWebhookToDTOToken converts a GitLab project or group hook to a DTO token representing its
+// secret token. The name of the hook is its host if it has no name.
+func ConvertWebhookToDTOToken(id int64, name, hookURL string, createdAt *time.Time) dto.Token {
+	if name == "" {
+		name = hookHost(hookURL)
+	}
+	return dto.Token{
+		ID:        id,
+		Name:      name,
+		CreatedAt: formatDate(createdAt),
+		Type:      dto.TypeWebhook,
+	}
+}
+
+// ConvertIntegrationToDTOToken converts a GitLab integration to a DTO token representing its credentials.
+func ConvertIntegrationToDTOToken(integration *gitlab.Integration) dto.Token {
+	return dto.Token{
+		ID:        integration.ID,
+		Name:      integration.Title,
+		CreatedAt: formatDate(integration.CreatedAt),
+		Type:      dto.TypeIntegration,
 	}
 }
 
@@ -151,3 +365,92 @@ func ConvertPersonalGitlabTokenToDTOTokens(personalGitlabTokens []*gitlab.Person
 	}
 	return tokens
 }
+
+// formatDate returns the date part of t, or an empty string if t is nil.
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(dto.DateFormat)
+}
+
+// ConvertPipelineTriggerToDTOTokens converts multiple GitLab pipeline trigger tokens to DTO tokens.
+func ConvertPipelineTriggerToDTOTokens(pipelineTriggers []*PipelineTrigger) []dto.Token {
+	tokens := make([]dto.Token, 0, len(pipelineTriggers))
+	for _, pipelineTrigger := range pipelineTriggers {
+		tokens = append(tokens, ConvertPipelineTriggerToDTOToken(pipelineTrigger))
+	}
+	return tokens
+}
+
+// ConvertProjectDeployKeyToDTOTokens converts multiple GitLab project deploy keys to DTO tokens.
+func ConvertProjectDeployKeyToDTOTokens(projectDeployKeys []*gitlab.ProjectDeployKey) []dto.Token {
+	tokens := make([]dto.Token, 0, len(projectDeployKeys))
+	for _, projectDeployKey := range projectDeployKeys {
+		tokens = append(tokens, ConvertProjectDeployKeyToDTOToken(projectDeployKey))
+	}
+	return tokens
+}
+
+// ConvertInstanceDeployKeyToDTOTokens converts multiple GitLab instance deploy keys to DTO tokens.
+func ConvertInstanceDeployKeyToDTOTokens(instanceDeployKeys []*gitlab.InstanceDeployKey) []dto.Token {
+	tokens := make([]dto.Token, 0, len(instanceDeployKeys))
+	for _, instanceDeployKey := range instanceDeployKeys {
+		tokens = append(tokens, ConvertInstanceDeployKeyToDTOToken(instanceDeployKey))
+	}
+	return tokens
+}
+
+// ConvertSSHKeyToDTOTokens converts multiple GitLab user SSH keys to DTO tokens.
+func ConvertSSHKeyToDTOTokens(sshKeys []*gitlab.SSHKey) []dto.Token {
+	tokens := make([]dto.Token, 0, len(sshKeys))
+	for _, sshKey := range sshKeys {
+		tokens = append(tokens, ConvertSSHKeyToDTOToken(sshKey))
+	}
+	return tokens
+}
+
+// ConvertImpersonationTokenToDTOTokens converts multiple GitLab impersonation tokens to DTO tokens.
+func ConvertImpersonationTokenToDTOTokens(impersonationTokens []*gitlab.ImpersonationToken) []dto.Token {
+	tokens := make([]dto.Token, 0, len(impersonationTokens))
+	for _, impersonationToken := range impersonationTokens {
+		tokens = append(tokens, ConvertImpersonationTokenToDTOToken(impersonationToken))
+	}
+	return tokens
+}
+
+// ConvertGPGKeyToDTOTokens converts multiple GitLab GPG keys to DTO tokens.
+func ConvertGPGKeyToDTOTokens(gpgKeys []*gitlab.GPGKey) []dto.Token {
+	tokens := make([]dto.Token, 0, len(gpgKeys))
+	for _, gpgKey := range gpgKeys {
+		tokens = append(tokens, ConvertGPGKeyToDTOToken(gpgKey))
+	}
+	return tokens
+}
+
+// ConvertOAuthApplicationToDTOTokens converts multiple GitLab OAuth applications to DTO tokens.
+func ConvertOAuthApplicationToDTOTokens(applications []*gitlab.Application) []dto.Token {
+	tokens := make([]dto.Token, 0, len(applications))
+	for _, application := range applications {
+		tokens = append(tokens, ConvertOAuthApplicationToDTOToken(application))
+	}
+	return tokens
+}
+
+// ConvertRunnerToDTOTokens converts multiple GitLab runners to DTO tokens.
+func ConvertRunnerToDTOTokens(runners []*Runner) []dto.Token {
+	tokens := make([]dto.Token, 0, len(runners))
+	for _, runner := range runners {
+		tokens = append(tokens, ConvertRunnerToDTOToken(runner))
+	}
+	return tokens
+}
+
+// ConvertAgentTokenToDTOTokens converts multiple tokens of a GitLab agent for Kubernetes to DTO tokens.
+func ConvertAgentTokenToDTOTokens(agent *gitlab.Agent, agentTokens []*gitlab.AgentToken) []dto.Token {
+	tokens := make([]dto.Token, 0, len(agentTokens))
+	for _, agentToken := range agentTokens {
+		tokens = append(tokens, ConvertAgentTokenToDTOToken(agent, agentToken))
+	}
+	return tokens
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/sgaunet/gitlab-token-expiration/pkg/app"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
 	"github.com/stretchr/testify/assert"
 	"gitlab.com/gitlab-org/api/client-go"
 )
@@ -237,6 +238,150 @@ func TestConvertPersonalGitlabTokenToDTOToken(t *testing.T) {
 	}
 }
 
+func TestConvertPipelineTriggerToDTOToken(t *testing.T) {
+	expiresAt := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	lastUsed := time.Date(2024, 11, 15, 10, 30, 0, 0, time.UTC)
+	deletedAt := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	
+	tests := []struct {
+		name             string
+		input            *app.PipelineTrigger
+		expected         string
+		expectedLastUsed string
+		expectedOwner    string
+		expectedRevoked  bool
+	}{
+		{
+			name: "with expiry date",
+			input: &app.PipelineTrigger{
+				PipelineTrigger: gitlab.PipelineTrigger{
+					ID:          123,
+					Description: "deploy-trigger",
+					LastUsed:    &lastUsed,
+					Owner:       &gitlab.User{Username: "alice"},
+				},
+				ExpiresAt: (*gitlab.ISOTime)(&expiresAt),
+			},
+			expected:         "2024-12-31",
+			expectedLastUsed: "2024-11-15",
+			expectedOwner:    "alice",
+		},
+		{
+			name: "without expiry date",
+			input: &app.PipelineTrigger{
+				PipelineTrigger: gitlab.PipelineTrigger{
+					ID:          456,
+					Description: "no-expiry-trigger",
+					DeletedAt:   &deletedAt,
+				},
+			},
+			expected:        "",
+			expectedRevoked: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := app.ConvertPipelineTriggerToDTOToken(tt.input)
+			
+			assert.Equal(t, tt.input.ID, result.ID)
+			assert.Equal(t, tt.input.Description, result.Name)
+			assert.Equal(t, tt.expectedRevoked, result.Revoked)
+			assert.Equal(t, tt.expected, result.ExpiresAt)
+			assert.Equal(t, tt.expectedLastUsed, result.LastUsedAt)
+			assert.Equal(t, tt.expectedOwner, result.Owner)
+			assert.Equal(t, "project", result.Source)
+			assert.Equal(t, "pipeline_trigger", result.Type)
+		})
+	}
+}
+
+func TestConvertDeployKeysAndSSHKeysToDTOToken(t *testing.T) {
+	expiresAt := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name               string
+		convert            func() dto.Token
+		expected           string
+		expectedSourceKind string
+		expectedType       string
+	}{
+		{
+			name: "project deploy key with expiry date",
+			convert: func() dto.Token {
+				return app.ConvertProjectDeployKeyToDTOToken(&gitlab.ProjectDeployKey{ID: 1, Title: "key", ExpiresAt: &expiresAt})
+			},
+			expected:           "2024-12-31",
+			expectedSourceKind: "project",
+			expectedType:       "deploy_key",
+		},
+		{
+			name: "instance deploy key without expiry date",
+			convert: func() dto.Token {
+				return app.ConvertInstanceDeployKeyToDTOToken(&gitlab.InstanceDeployKey{ID: 1, Title: "key"})
+			},
+			expected:           "",
+			expectedSourceKind: "instance",
+			expectedType:       "deploy_key",
+		},
+		{
+			name: "user SSH key with expiry date",
+			convert: func() dto.Token {
+				return app.ConvertSSHKeyToDTOToken(&gitlab.SSHKey{ID: 1, Title: "key", ExpiresAt: &expiresAt})
+			},
+			expected:           "2024-12-31",
+			expectedSourceKind: "user",
+			expectedType:       "ssh_key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.convert()
+
+			assert.Equal(t, int64(1), result.ID)
+			assert.Equal(t, "key", result.Name)
+			assert.False(t, result.Revoked)
+			assert.Equal(t, tt.expected, result.ExpiresAt)
+			assert.Equal(t, tt.expectedSourceKind, result.SourceKind)
+			assert.Equal(t, tt.expectedType, result.Type)
+		})
+	}
+}
+
+func TestConvertSSHKeyToDTOTokens(t *testing.T) {
+	expiresAt := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	result := app.ConvertSSHKeyToDTOTokens([]*gitlab.SSHKey{
+		{ID: 1, Title: "laptop", ExpiresAt: &expiresAt},
+		{ID: 2, Title: "ci"},
+	})
+
+	assert.Len(t, result, 2)
+	assert.Equal(t, "laptop", result[0].Name)
+	assert.Equal(t, "2024-12-31", result[0].ExpiresAt)
+	assert.Equal(t, "ci", result[1].Name)
+	assert.Equal(t, "", result[1].ExpiresAt)
+}
+
+func TestConvertAccessTokenLastUsedAt(t *testing.T) {
+	lastUsedAt := time.Date(2024, 11, 15, 10, 30, 0, 0, time.UTC)
+	token := gitlab.PersonalAccessToken{
+		ID:         123,
+		Name:       "used-token",
+		LastUsedAt: &lastUsedAt,
+	}
+
+	assert.Equal(t, "2024-11-15", app.ConvertPersonalGitlabTokenToDTOToken(&token).LastUsedAt)
+	assert.Equal(t, "2024-11-15",
+		app.ConvertGroupAccessTokenToDTOToken(&gitlab.GroupAccessToken{PersonalAccessToken: token}).LastUsedAt)
+	assert.Equal(t, "2024-11-15",
+		app.ConvertProjectAccessTokenToDTOToken(&gitlab.ProjectAccessToken{PersonalAccessToken: token}).LastUsedAt)
+
+	token.LastUsedAt = nil
+	assert.Equal(t, "", app.ConvertPersonalGitlabTokenToDTOToken(&token).LastUsedAt)
+}
+
 func TestConvertGroupAccessTokenToDTOTokens(t *testing.T) {
 	expiresAt := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
 	
@@ -390,4 +535,70 @@ func TestConvertPersonalGitlabTokenToDTOTokens(t *testing.T) {
 	assert.Equal(t, "personal-2", result[1].Name)
 	assert.Equal(t, "", result[1].ExpiresAt)
 	assert.Equal(t, "personal_access_token", result[1].Type)
-}
\ No newline at end of file
+}
+
+func TestConvertPipelineTriggerToDTOTokens(t *testing.T) {
+	expiresAt := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	
+	input := []*app.PipelineTrigger{
+		{
+			PipelineTrigger: gitlab.PipelineTrigger{
+				ID:          1,
+				Description: "trigger-1",
+			},
+			ExpiresAt: (*gitlab.ISOTime)(&expiresAt),
+		},
+		{
+			PipelineTrigger: gitlab.PipelineTrigger{
+				ID:          2,
+				Description: "trigger-2",
+			},
+		},
+	}
+
+	result := app.ConvertPipelineTriggerToDTOTokens(input)
+
+	assert.Len(t, result, 2)
+	assert.Equal(t, int64(1), result[0].ID)
+	assert.Equal(t, "trigger-1", result[0].Name)
+	assert.Equal(t, "2024-12-31", result[0].ExpiresAt)
+	assert.Equal(t, "pipeline_trigger", result[0].Type)
+	assert.Equal(t, int64(2), result[1].ID)
+	assert.Equal(t, "trigger-2", result[1].Name)
+	assert.Equal(t, "", result[1].ExpiresAt)
+	assert.Equal(t, "pipeline_trigger", result[1].Type)
+}
+
+func TestConvertUserCredentialsToDTOTokens(t *testing.T) {
+	expiresAt := gitlab.ISOTime(time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC))
+	lastUsedAt := time.Date(2024, 11, 15, 10, 30, 0, 0, time.UTC)
+
+	impersonationTokens := app.ConvertImpersonationTokenToDTOTokens([]*gitlab.ImpersonationToken{
+		{ID: 1, Name: "support", Scopes: []string{"api"}, ExpiresAt: &expiresAt, LastUsedAt: &lastUsedAt},
+		{ID: 2, Name: "old", Revoked: true},
+	})
+	assert.Len(t, impersonationTokens, 2)
+	assert.Equal(t, "support", impersonationTokens[0].Name)
+	assert.Equal(t, "2024-12-31", impersonationTokens[0].ExpiresAt)
+	assert.Equal(t, "2024-11-15", impersonationTokens[0].LastUsedAt)
+	assert.Equal(t, []string{"api"}, impersonationTokens[0].Scopes)
+	assert.Equal(t, "impersonation_token", impersonationTokens[0].Type)
+	assert.True(t, impersonationTokens[0].TracksUsage())
+	assert.True(t, impersonationTokens[1].Revoked)
+	assert.Equal(t, "", impersonationTokens[1].ExpiresAt)
+
+	gpgKeys := app.ConvertGPGKeyToDTOTokens([]*gitlab.GPGKey{{ID: 7, Key: "-----BEGIN PGP PUBLIC KEY BLOCK-----"}})
+	assert.Len(t, gpgKeys, 1)
+	assert.Equal(t, int64(7), gpgKeys[0].ID)
+	assert.Equal(t, "GPG key 7", gpgKeys[0].Name)
+	assert.Equal(t, "", gpgKeys[0].ExpiresAt)
+	assert.Equal(t, "user", gpgKeys[0].SourceKind)
+	assert.Equal(t, "gpg_key", gpgKeys[0].Type)
+
+	applications := app.ConvertOAuthApplicationToDTOTokens([]*gitlab.Application{{ID: 3, ApplicationName: "ci-app"}})
+	assert.Len(t, applications, 1)
+	assert.Equal(t, "ci-app", applications[0].Name)
+	assert.Equal(t, "instance", applications[0].Source)
+	assert.Equal(t, "instance", applications[0].SourceKind)
+	assert.Equal(t, "oauth_application", applications[0].Type)
+}
@@ -0,0 +1,348 @@
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// Statuses of the checks of a diagnosis.
+const (
+	CheckOK      = "ok"
+	CheckWarning = "warning"
+	CheckFailed  = "failed"
+	CheckSkipped = "skipped"
+)
+
+// probeTimeout is the timeout of the request checking that GitLab is reachable.
+const probeTimeout = 10 * time.Second
+
+// tokenExpirationWarning is the duration before the expiration of the token from which
+// the diagnosis warns about it.
+const tokenExpirationWarning = 7 * 24 * time.Hour
+
+// Check is the result of a check of a diagnosis, or the availability of a feature.
+type Check struct {
+	Name   string
+	Status string
+	Detail string
+}
+
+// Diagnosis is the result of the checks of the configuration, and the features
+// available with it.
+type Diagnosis struct {
+	Checks   []Check
+	Features []Check
+}
+
+// Failed returns true if a check failed.
+func (d *Diagnosis) Failed() bool {
+	return slices.ContainsFunc(d.Checks, func(c Check) bool { return c.Status == CheckFailed })
+}
+
+// diagnoser runs the checks of a diagnosis. Once a blocking check failed, the next checks are skipped.
+type diagnoser struct {
+	a         *App
+	diagnosis *Diagnosis
+	blocked   bool
+	scopes    []string // nil if the token could not be inspected
+	user      *gitlab.User
+	roles     map[string]gitlab.AccessLevelValue
+}
+
+// Diagnose checks the URL of GitLab, its reachability, TLS, the token, its scopes and the
+// current user. If groupID or projectID is not 0, the role of the current user on the group
+// or project is checked too. It then reports which features work with this configuration.
+func (a *App) Diagnose(ctx context.Context, groupID, projectID int64) *Diagnosis {
+	d := &diagnoser{a: a, diagnosis: &Diagnosis{}, roles: map[string]gitlab.AccessLevelValue{}}
+
+	d.run("URL", true, a.checkURL)
+	var probe *http.Response
+	var probeErr error
+	if !d.blocked {
+		probe, probeErr = a.probe(ctx)
+		if probe != nil {
+			defer probe.Body.Close()
+		}
+	}
+	d.run("Reachability", true, func() (string, string) { return checkReachability(probe, probeErr) })
+	d.run("TLS", true, func() (string, string) { return a.checkTLS(probe, probeErr) })
+	d.run("Token", true, func() (string, string) { return d.checkToken(ctx) })
+	d.run("Scopes", false, d.checkScopes)
+	d.run("User", true, func() (string, string) { return d.checkUser(ctx) })
+	if groupID != 0 {
+		d.run("Group role", false, func() (string, string) {
+			return d.checkRole(dto.SourceKindGroup, groupID, func() (gitlab.AccessLevelValue, error) {
+				member, _, err := a.gitlabClient.GroupMembers.GetInheritedGroupMember(groupID, d.user.ID,
+					gitlab.WithContext(ctx))
+				if err != nil {
+					return 0, err //nolint:wrapcheck // wrapped by checkRole
+				}
+				return member.AccessLevel, nil
+			})
+		})
+	}
+	if projectID != 0 {
+		d.run("Project role", false, func() (string, string) {
+			return d.checkRole(dto.SourceKindProject, projectID, func() (gitlab.AccessLevelValue, error) {
+				member, _, err := a.gitlabClient.ProjectMembers.GetInheritedProjectMember(projectID, d.user.ID,
+					gitlab.WithContext(ctx))
+				if err != nil {
+					return 0, err //nolint:wrapcheck // wrapped by checkRole
+				}
+				return member.AccessLevel, nil
+			})
+		})
+	}
+	if !d.blocked {
+		d.features(groupID != 0, projectID != 0)
+	}
+	return d.diagnosis
+}
+
+// run runs the check unless a previous blocking check failed.
+func (d *diagnoser) run(name string, blocking bool, check func() (string, string)) {
+	if d.blocked {
+		d.diagnosis.Checks = append(d.diagnosis.Checks, Check{Name: name, Status: CheckSkipped,
+			Detail: "a previous check failed"})
+		return
+	}
+	status, detail := check()
+	d.diagnosis.Checks = append(d.diagnosis.Checks, Check{Name: name, Status: status, Detail: detail})
+	if blocking && status == CheckFailed {
+		d.blocked = true
+	}
+}
+
+// checkURL checks the URL of the GitLab API.
+func (a *App) checkURL() (string, string) {
+	if a.clientErr != nil {
+		return CheckFailed, fmt.Sprintf("invalid GitLab URL: %v", a.clientErr)
+	}
+	u, err := url.Parse(a.BaseURL())
+	if err != nil {
+		return CheckFailed, fmt.Sprintf("invalid GitLab URL: %v", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return CheckFailed, fmt.Sprintf("invalid GitLab URL %s: it must start with https://", a.BaseURL())
+	}
+	if u.Scheme == "http" {
+		return CheckWarning, a.BaseURL() + " (the token is sent in clear text)"
+	}
+	return CheckOK, a.BaseURL()
+}
+
+// probe sends an unauthenticated request to the GitLab API, without the retries of the retry transport.
+func (a *App) probe(ctx context.Context) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.BaseURL()+"version", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	client := a.httpClient
+	if a.logTransport != nil {
+		client = &http.Client{Transport: a.logTransport}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach GitLab: %w", err)
+	}
+	return resp, nil
+}
+
+// checkReachability checks that GitLab answered the probe.
+func checkReachability(resp *http.Response, err error) (string, string) {
+	switch {
+	case err != nil && isTLSError(err):
+		return CheckOK, "connected"
+	case err != nil:
+		return CheckFailed, err.Error()
+	case resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusUnauthorized:
+		return CheckWarning, fmt.Sprintf("unexpected status %d, is it the URL of a GitLab instance?", resp.StatusCode)
+	default:
+		return CheckOK, fmt.Sprintf("HTTP %d", resp.StatusCode)
+	}
+}
+
+// checkTLS checks the TLS connection of the probe.
+func (a *App) checkTLS(resp *http.Response, err error) (string, string) {
+	if err != nil {
+		return CheckFailed, err.Error()
+	}
+	if resp.TLS == nil {
+		return CheckWarning, "not used"
+	}
+	detail := tls.VersionName(resp.TLS.Version)
+	if len(resp.TLS.PeerCertificates) > 0 {
+		cert := resp.TLS.PeerCertificates[0]
+		detail += fmt.Sprintf(", certificate issued by %s, expires on %s",
+			cert.Issuer.CommonName, cert.NotAfter.Format(time.DateOnly))
+	}
+	if t, ok := a.config.Transport.(*http.Transport); ok && t.TLSClientConfig != nil &&
+		t.TLSClientConfig.InsecureSkipVerify {
+		return CheckWarning, detail + " (certificate verification disabled)"
+	}
+	return CheckOK, detail
+}
+
+// isTLSError returns true if the error comes from the TLS handshake.
+func isTLSError(err error) bool {
+	var verificationErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	return errors.As(err, &verificationErr) || errors.As(err, &recordErr) || errors.As(err, &alertErr) ||
+		errors.As(err, &unknownAuthorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr)
+}
+
+// checkToken checks the token with /personal_access_tokens/self, and records its scopes.
+func (d *diagnoser) checkToken(ctx context.Context) (string, string) {
+	if d.a.config.Token == "" {
+		return CheckFailed, "no token"
+	}
+	pat, resp, err := d.a.gitlabClient.PersonalAccessTokens.GetSinglePersonalAccessToken(gitlab.WithContext(ctx))
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+			return CheckFailed, "the token is invalid, expired or revoked"
+		}
+		// e.g. an OAuth token, or a GitLab version without this endpoint
+		return CheckWarning, fmt.Sprintf("the token could not be inspected: %v", err)
+	}
+	d.scopes = pat.Scopes
+	if pat.Revoked || !pat.Active {
+		return CheckFailed, fmt.Sprintf("personal access token %q is not active", pat.Name)
+	}
+	if pat.ExpiresAt == nil {
+		return CheckOK, fmt.Sprintf("personal access token %q, never expires", pat.Name)
+	}
+	expiresAt := time.Time(*pat.ExpiresAt)
+	detail := fmt.Sprintf("personal access token %q, expires on %s", pat.Name, expiresAt.Format(time.DateOnly))
+	if time.Until(expiresAt) < tokenExpirationWarning {
+		return CheckWarning, detail
+	}
+	return CheckOK, detail
+}
+
+// checkScopes checks that the token can read the API.
+func (d *diagnoser) checkScopes() (string, string) {
+	if d.scopes == nil {
+		return CheckSkipped, "the scopes of the token are unknown"
+	}
+	scopes := strings.Join(d.scopes, ", ")
+	if !d.canReadAPI() {
+		return CheckFailed, fmt.Sprintf("%s (the api or read_api scope is required to list the tokens)", scopes)
+	}
+	return CheckOK, scopes
+}
+
+// checkUser checks the current user.
+func (d *diagnoser) checkUser(ctx context.Context) (string, string) {
+	user, _, err := d.a.gitlabClient.Users.CurrentUser(gitlab.WithContext(ctx))
+	if err != nil {
+		return CheckFailed, fmt.Sprintf("failed to get current user: %v", err)
+	}
+	d.user = user
+	if user.IsAdmin {
+		return CheckOK, user.Username + " (administrator)"
+	}
+	return CheckOK, user.Username
+}
+
+// checkRole checks the role of the current user on a group or project, and records it.
+func (d *diagnoser) checkRole(kind string, id int64, role func() (gitlab.AccessLevelValue, error)) (string, string) {
+	level, err := role()
+	if err != nil {
+		var errResp *gitlab.ErrorResponse
+		if errors.As(err, &errResp) && errResp.Response.StatusCode == http.StatusNotFound {
+			if d.user.IsAdmin {
+				d.roles[kind] = gitlab.AdminPermissions
+				return CheckOK, fmt.Sprintf("administrator, not a member of %s %d", kind, id)
+			}
+			return CheckFailed, fmt.Sprintf("not a member of %s %d, or it does not exist", kind, id)
+		}
+		return CheckFailed, fmt.Sprintf("failed to get role on %s %d: %v", kind, id, err)
+	}
+	d.roles[kind] = level
+	return CheckOK, fmt.Sprintf("%s on %s %d", accessLevelName(level), kind, id)
+}
+
+// canReadAPI returns true if the token has a scope allowing to read the API, or if its scopes are unknown.
+func (d *diagnoser) canReadAPI() bool {
+	return d.scopes == nil || slices.Contains(d.scopes, "api") || slices.Contains(d.scopes, "read_api")
+}
+
+// features reports the features available with the token, its scopes and roles.
+func (d *diagnoser) features(checkedGroup, checkedProject bool) {
+	readAPI := d.canReadAPI()
+	readUser := readAPI || slices.Contains(d.scopes, "read_user")
+	d.feature("pat: personal access tokens", readAPI, "requires the api or read_api scope")
+	d.feature("user: SSH and GPG keys", readUser, "requires the api, read_api or read_user scope")
+	d.feature("user, keys --all-users: impersonation tokens, OAuth applications and keys of all users",
+		readAPI && d.user.IsAdmin, "requires an administrator")
+	d.roleFeature("group: group access and deploy tokens", dto.SourceKindGroup, checkedGroup,
+		gitlab.OwnerPermissions, readAPI)
+	d.roleFeature("project, group: project access and deploy tokens, pipeline triggers", dto.SourceKindProject,
+		checkedProject, gitlab.MaintainerPermissions, readAPI)
+}
+
+// feature records the availability of a feature.
+func (d *diagnoser) feature(name string, available bool, requirement string) {
+	if available {
+		d.diagnosis.Features = append(d.diagnosis.Features, Check{Name: name, Status: CheckOK})
+		return
+	}
+	d.diagnosis.Features = append(d.diagnosis.Features, Check{Name: name, Status: CheckFailed, Detail: requirement})
+}
+
+// roleFeature records the availability of a feature requiring a role on the groups or projects.
+func (d *diagnoser) roleFeature(name, kind string, checked bool, level gitlab.AccessLevelValue, readAPI bool) {
+	requirement := fmt.Sprintf("requires the %s role", accessLevelName(level))
+	switch {
+	case !readAPI:
+		d.feature(name, false, "requires the api or read_api scope")
+	case d.user.IsAdmin:
+		d.feature(name, true, "")
+	case !checked:
+		d.diagnosis.Features = append(d.diagnosis.Features, Check{Name: name, Status: CheckWarning,
+			Detail: requirement + ", give a " + kind + " to check it"})
+	default:
+		d.feature(name, d.roles[kind] >= level, requirement)
+	}
+}
+
+// accessLevelName returns the name of the role of an access level.
+func accessLevelName(level gitlab.AccessLevelValue) string {
+	switch {
+	case level >= gitlab.AdminPermissions:
+		return "Admin"
+	case level >= gitlab.OwnerPermissions:
+		return "Owner"
+	case level >= gitlab.MaintainerPermissions:
+		return "Maintainer"
+	case level >= gitlab.DeveloperPermissions:
+		return "Developer"
+	case level >= gitlab.ReporterPermissions:
+		return "Reporter"
+	case level >= gitlab.PlannerPermissions:
+		return "Planner"
+	case level >= gitlab.GuestPermissions:
+		return "Guest"
+	case level >= gitlab.MinimalAccessPermissions:
+		return "Minimal Access"
+	default:
+		return "No access"
+	}
+}
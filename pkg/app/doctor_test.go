@@ -0,0 +1,173 @@
+package app_test
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/app"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDoctorServer(t *testing.T, tokenStatus int, scopes string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/version", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	mux.HandleFunc("/api/v4/personal_access_tokens/self", func(w http.ResponseWriter, _ *http.Request) {
+		if tokenStatus != http.StatusOK {
+			w.WriteHeader(tokenStatus)
+			fmt.Fprint(w, `{"message": "401 Unauthorized"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"id": 1, "name": "doctor", "active": true, "scopes": %s, "expires_at": "2099-01-01"}`, scopes)
+	})
+	mux.HandleFunc("/api/v4/user", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"id": 1, "username": "alice"}`)
+	})
+	mux.HandleFunc("/api/v4/groups/5/members/all/1", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"id": 1, "username": "alice", "access_level": 50}`)
+	})
+	mux.HandleFunc("/api/v4/projects/7/members/all/1", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message": "404 Not found"}`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// statuses returns the status of the checks by name.
+func statuses(checks []app.Check) map[string]string {
+	res := map[string]string{}
+	for _, check := range checks {
+		res[check.Name] = check.Status
+	}
+	return res
+}
+
+func TestApp_Diagnose(t *testing.T) {
+	server := newDoctorServer(t, http.StatusOK, `["read_api"]`)
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL), app.WithToken("glpat-test"))
+
+	diagnosis := a.Diagnose(context.Background(), 5, 7)
+
+	assert.Equal(t, map[string]string{
+		"URL":          app.CheckWarning, // http
+		"Reachability": app.CheckOK,
+		"TLS":          app.CheckWarning,
+		"Token":        app.CheckOK,
+		"Scopes":       app.CheckOK,
+		"User":         app.CheckOK,
+		"Group role":   app.CheckOK,
+		"Project role": app.CheckFailed,
+	}, statuses(diagnosis.Checks))
+	assert.True(t, diagnosis.Failed())
+
+	features := statuses(diagnosis.Features)
+	require.Len(t, features, 5)
+	assert.Equal(t, app.CheckOK, features["pat: personal access tokens"])
+	assert.Equal(t, app.CheckFailed,
+		features["user, keys --all-users: impersonation tokens, OAuth applications and keys of all users"])
+	assert.Equal(t, app.CheckOK, features["group: group access and deploy tokens"])
+	assert.Equal(t, app.CheckFailed, features["project, group: project access and deploy tokens, pipeline triggers"])
+}
+
+func TestApp_Diagnose_MissingScope(t *testing.T) {
+	server := newDoctorServer(t, http.StatusOK, `["read_user"]`)
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL), app.WithToken("glpat-test"))
+
+	diagnosis := a.Diagnose(context.Background(), 0, 0)
+
+	assert.Equal(t, app.CheckFailed, statuses(diagnosis.Checks)["Scopes"])
+	features := statuses(diagnosis.Features)
+	assert.Equal(t, app.CheckFailed, features["pat: personal access tokens"])
+	assert.Equal(t, app.CheckOK, features["user: SSH and GPG keys"])
+	assert.Equal(t, app.CheckFailed, features["group: group access and deploy tokens"])
+}
+
+func TestApp_Diagnose_InvalidToken(t *testing.T) {
+	server := newDoctorServer(t, http.StatusUnauthorized, "")
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL), app.WithToken("glpat-test"))
+
+	diagnosis := a.Diagnose(context.Background(), 5, 0)
+
+	checks := statuses(diagnosis.Checks)
+	assert.Equal(t, app.CheckFailed, checks["Token"])
+	assert.Equal(t, app.CheckSkipped, checks["User"])
+	assert.Equal(t, app.CheckSkipped, checks["Group role"])
+	assert.Empty(t, diagnosis.Features)
+	assert.True(t, diagnosis.Failed())
+}
+
+func TestApp_Diagnose_NoToken(t *testing.T) {
+	server := newDoctorServer(t, http.StatusOK, `["api"]`)
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL), app.WithToken(""))
+
+	diagnosis := a.Diagnose(context.Background(), 0, 0)
+
+	assert.Equal(t, app.CheckFailed, statuses(diagnosis.Checks)["Token"])
+}
+
+func TestApp_Diagnose_UntrustedCertificate(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL), app.WithToken("glpat-test"))
+
+	diagnosis := a.Diagnose(context.Background(), 0, 0)
+
+	checks := statuses(diagnosis.Checks)
+	assert.Equal(t, app.CheckOK, checks["URL"])
+	assert.Equal(t, app.CheckOK, checks["Reachability"])
+	assert.Equal(t, app.CheckFailed, checks["TLS"])
+	assert.Equal(t, app.CheckSkipped, checks["Token"])
+}
+
+func TestApp_Diagnose_InvalidURL(t *testing.T) {
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(":bad"), app.WithToken("glpat-test"))
+	require.Error(t, a.Err())
+
+	diagnosis := a.Diagnose(context.Background(), 0, 0)
+
+	checks := statuses(diagnosis.Checks)
+	assert.Equal(t, app.CheckFailed, checks["URL"])
+	assert.Equal(t, app.CheckSkipped, checks["Reachability"])
+}
+
+func TestApp_Diagnose_HTTPTransport(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+	caCert := filepath.Join(t.TempDir(), "ca.crt")
+	require.NoError(t, os.WriteFile(caCert,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600))
+
+	tests := []struct {
+		name string
+		opts []transport.HTTPOption
+		want string
+	}{
+		{name: "CA certificate", opts: []transport.HTTPOption{transport.WithCACert(caCert)}, want: app.CheckOK},
+		{name: "insecure", opts: []transport.HTTPOption{transport.WithInsecureSkipVerify(true)}, want: app.CheckWarning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpTransport, err := transport.NewHTTPTransport(tt.opts...)
+			require.NoError(t, err)
+			a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL), app.WithToken("glpat-test"),
+				app.WithHTTPTransport(httpTransport))
+
+			diagnosis := a.Diagnose(context.Background(), 0, 0)
+
+			assert.Equal(t, tt.want, statuses(diagnosis.Checks)["TLS"])
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SkippedSource is a group, project or user whose tokens could not be retrieved during a scan.
+type SkippedSource struct {
+	SourceKind string
+	SourceID   int64
+	Source     string
+	Err        error
+}
+
+func (s SkippedSource) String() string {
+	switch {
+	case s.SourceKind == "":
+		return s.Err.Error()
+	case s.Source == "" && s.SourceID == 0:
+		return fmt.Sprintf("%s: %v", s.SourceKind, s.Err)
+	case s.Source == "":
+		return fmt.Sprintf("%s %d: %v", s.SourceKind, s.SourceID, s.Err)
+	case s.SourceID == 0:
+		return fmt.Sprintf("%s %s: %v", s.SourceKind, s.Source, s.Err)
+	default:
+		return fmt.Sprintf("%s %s (id %d): %v", s.SourceKind, s.Source, s.SourceID, s.Err)
+	}
+}
+
+// ScanError is returned along with the tokens retrieved when the tokens of some sources
+// could not be retrieved (e.g. a subgroup where the token lacks the Maintainer role).
+// The scan goes on with the other sources.
+type ScanError struct {
+	Skipped []SkippedSource
+}
+
+func (e *ScanError) Error() string {
+	reasons := make([]string, 0, len(e.Skipped))
+	for _, s := range e.Skipped {
+		reasons = append(reasons, s.String())
+	}
+	return fmt.Sprintf("%d source(s) skipped: %s", len(e.Skipped), strings.Join(reasons, "; "))
+}
+
+// Unwrap returns the errors of the skipped sources.
+func (e *ScanError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Skipped))
+	for _, s := range e.Skipped {
+		errs = append(errs, s.Err)
+	}
+	return errs
+}
+
+// skip records a source whose tokens could not be retrieved.
+func (e *ScanError) skip(sourceKind string, sourceID int64, source string, err error) {
+	e.Skipped = append(e.Skipped, SkippedSource{SourceKind: sourceKind, SourceID: sourceID, Source: source, Err: err})
+}
+
+// merge records the sources skipped in err. An error that is not a ScanError is recorded
+// as a skipped source without source.
+func (e *ScanError) merge(err error) {
+	if err == nil {
+		return
+	}
+	var scanErr *ScanError
+	if errors.As(err, &scanErr) {
+		e.Skipped = append(e.Skipped, scanErr.Skipped...)
+		return
+	}
+	e.Skipped = append(e.Skipped, SkippedSource{Err: err})
+}
+
+// errOrNil returns the error if sources were skipped, nil otherwise.
+func (e *ScanError) errOrNil() error {
+	if len(e.Skipped) == 0 {
+		return nil
+	}
+	return e
+}
+
+// MergeScanErrors returns a ScanError holding the sources skipped in all the errors,
+// or nil if no source was skipped. It returns the first error that is not a ScanError,
+// if any, since the scan failed.
+func MergeScanErrors(errs ...error) error {
+	res := &ScanError{}
+	for _, err := range errs {
+		var scanErr *ScanError
+		if err != nil && !errors.As(err, &scanErr) {
+			return err
+		}
+		res.merge(err)
+	}
+	return res.errOrNil()
+}
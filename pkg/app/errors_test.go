@@ -0,0 +1,160 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/app"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+func forbidden(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusForbidden)
+	fmt.Fprint(w, `{"message": "403 Forbidden"}`)
+}
+
+func TestApp_GetTokensOfGroups_PartialFailure(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/groups/1/access_tokens", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[{"id": 10, "name": "ci", "expires_at": "2030-01-01"}]`)
+	})
+	mux.HandleFunc("/api/v4/groups/1/deploy_tokens", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	// The token lacks the Maintainer role in the subgroup
+	mux.HandleFunc("/api/v4/groups/2/access_tokens", forbidden)
+	mux.HandleFunc("/api/v4/groups/2/deploy_tokens", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[{"id": 20, "name": "registry", "expires_at": "2030-01-01T00:00:00Z"}]`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL))
+	groups := []*gitlab.Group{{ID: 2, Path: "child"}, {ID: 1, Path: "parent"}}
+	tokens, err := a.GetTokensOfGroups(context.Background(), groups)
+
+	var scanErr *app.ScanError
+	require.ErrorAs(t, err, &scanErr)
+	require.Len(t, scanErr.Skipped, 1)
+	assert.Equal(t, dto.SourceKindGroup, scanErr.Skipped[0].SourceKind)
+	assert.Equal(t, int64(2), scanErr.Skipped[0].SourceID)
+	assert.Equal(t, "child", scanErr.Skipped[0].Source)
+	assert.Contains(t, scanErr.Skipped[0].String(), "403")
+
+	require.Len(t, tokens, 2, "the scan goes on after a failure")
+	assert.Equal(t, "registry", tokens[0].Name)
+	assert.Equal(t, "ci", tokens[1].Name)
+}
+
+func TestApp_GetTokensOfProjects_OneSkipPerSource(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/1/access_tokens", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[{"id": 10, "name": "ci", "expires_at": "2030-01-01"}]`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/triggers", forbidden)
+	mux.HandleFunc("/api/v4/projects/1/deploy_keys", forbidden)
+	// The owners cannot be resolved, but the tokens are listed
+	mux.HandleFunc("/api/v4/projects/1/members/all", forbidden)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL), app.WithOwners(true))
+	projects := []*gitlab.Project{{ID: 1, PathWithNamespace: "group/project"}}
+	tokens, err := a.GetTokensOfProjects(context.Background(), projects)
+
+	var scanErr *app.ScanError
+	require.ErrorAs(t, err, &scanErr)
+	require.Len(t, scanErr.Skipped, 1)
+	assert.Contains(t, scanErr.Skipped[0].String(), "pipeline triggers")
+	assert.Contains(t, scanErr.Skipped[0].String(), "deploy keys")
+	assert.NotContains(t, scanErr.Skipped[0].String(), "members")
+	require.Len(t, tokens, 1)
+	assert.Empty(t, tokens[0].Owner)
+}
+
+func TestApp_GetTokensOfGroups_OwnersNotResolved(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/groups/1/access_tokens", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[{"id": 10, "name": "ci", "expires_at": "2030-01-01"}]`)
+	})
+	mux.HandleFunc("/api/v4/groups/1/deploy_tokens", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/api/v4/groups/1/members/all", forbidden)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL), app.WithOwners(true))
+	tokens, err := a.GetTokensOfGroups(context.Background(), []*gitlab.Group{{ID: 1, Path: "parent"}})
+
+	require.NoError(t, err, "the group is not skipped")
+	require.Len(t, tokens, 1)
+}
+
+func TestApp_GetSSHKeysOfAllUsers_PartialFailure(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/users", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[{"id": 1, "username": "alice"}, {"id": 2, "username": "bob"}]`)
+	})
+	mux.HandleFunc("/api/v4/users/1/keys", forbidden)
+	mux.HandleFunc("/api/v4/users/2/keys", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[{"id": 20, "title": "laptop", "expires_at": "2030-01-01T00:00:00Z"}]`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL))
+	tokens, err := a.GetSSHKeysOfAllUsers(context.Background())
+
+	var scanErr *app.ScanError
+	require.ErrorAs(t, err, &scanErr)
+	require.Len(t, scanErr.Skipped, 1)
+	assert.Equal(t, dto.SourceKindUser, scanErr.Skipped[0].SourceKind)
+	assert.Equal(t, "alice", scanErr.Skipped[0].Source)
+	require.Len(t, tokens, 1, "the scan goes on after a failure")
+	assert.Equal(t, "bob", tokens[0].Owner)
+}
+
+func TestApp_GetRecursiveProjectsOfGroup_PartialFailure(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/groups/1/projects", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[{"id": 100, "path_with_namespace": "parent/a"}]`)
+	})
+	mux.HandleFunc("/api/v4/groups/1/descendant_groups", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[{"id": 2, "path": "child"}]`)
+	})
+	mux.HandleFunc("/api/v4/groups/2/projects", forbidden)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL))
+	projects, err := a.GetRecursiveProjectsOfGroup(1)
+
+	var scanErr *app.ScanError
+	require.ErrorAs(t, err, &scanErr)
+	require.Len(t, scanErr.Skipped, 1)
+	assert.Equal(t, int64(2), scanErr.Skipped[0].SourceID)
+	require.Len(t, projects, 1)
+	assert.Equal(t, int64(100), projects[0].ID)
+}
+
+func TestMergeScanErrors(t *testing.T) {
+	scanErr := &app.ScanError{Skipped: []app.SkippedSource{{SourceKind: dto.SourceKindGroup, SourceID: 1, Err: errors.New("forbidden")}}}
+	fatal := errors.New("unauthorized")
+
+	require.NoError(t, app.MergeScanErrors(nil, nil))
+	assert.Equal(t, fatal, app.MergeScanErrors(scanErr, fatal))
+
+	err := app.MergeScanErrors(scanErr, nil, scanErr)
+	var merged *app.ScanError
+	require.ErrorAs(t, err, &merged)
+	assert.Len(t, merged.Skipped, 2)
+	assert.Equal(t, "2 source(s) skipped: group 1: forbidden; group 1: forbidden", err.Error())
+}
@@ -0,0 +1,283 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// APIs used to walk the hierarchy of a group.
+const (
+	RESTAPI    = "rest"
+	GraphQLAPI = "graphql"
+)
+
+// graphQLPageSize is the number of nodes requested per page, the maximum allowed by GitLab.
+const graphQLPageSize = 100
+
+// ErrGroupNotFound is returned when a group is not found through the GraphQL API.
+var ErrGroupNotFound = errors.New("group not found")
+
+// descendantGroupsQuery lists the descendant groups of a group.
+const descendantGroupsQuery = `query($fullPath: ID!, $first: Int, $after: String) {
+  group(fullPath: $fullPath) {
+    descendantGroups(first: $first, after: $after) {
+      pageInfo { hasNextPage endCursor }
+      nodes { id name path fullPath webUrl parent { id } }
+    }
+  }
+}`
+
+// groupProjectsQuery lists the projects of a group and of its descendant groups.
+// The projects shared with the groups are not listed.
+const groupProjectsQuery = `query($fullPath: ID!, $includeArchived: Boolean, $first: Int, $after: String) {
+  group(fullPath: $fullPath) {
+    projects(includeSubgroups: true, includeArchived: $includeArchived, first: $first, after: $after) {
+      pageInfo { hasNextPage endCursor }
+      nodes { id name path fullPath webUrl archived }
+    }
+  }
+}`
+
+// pageInfoGQL is the pagination metadata of a GraphQL connection.
+type pageInfoGQL struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// groupGQL is a group returned by the GraphQL API.
+type groupGQL struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	FullPath string `json:"fullPath"`
+	WebURL   string `json:"webUrl"`
+	Parent   *struct {
+		ID string `json:"id"`
+	} `json:"parent"`
+}
+
+// projectGQL is a project returned by the GraphQL API.
+type projectGQL struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	FullPath string `json:"fullPath"`
+	WebURL   string `json:"webUrl"`
+	Archived bool   `json:"archived"`
+}
+
+// connectionGQL is a page of nodes of a GraphQL connection.
+type connectionGQL[T any] struct {
+	PageInfo pageInfoGQL `json:"pageInfo"`
+	Nodes    []T         `json:"nodes"`
+}
+
+// WithAPI sets the API used to walk the hierarchy of a group: RESTAPI (default) or GraphQLAPI.
+// The GraphQL API lists the descendant groups and projects of a group in a few batched queries
+// instead of one request per group. The tokens are still retrieved with the REST API since
+// the GraphQL schema does not expose the access and deploy tokens. The GraphQL API does not
+// list the projects shared with the groups, and cannot tell the forks.
+func WithAPI(api string) Option {
+	return func(a *App) {
+		a.api = api
+	}
+}
+
+// getGroupHierarchyWithGraphQL returns the group along with its descendant groups, and the projects
+// of the group and of its descendant groups, retrieved with the GraphQL API. Like with the REST API,
+// the groups and projects that cannot be retrieved are listed in a *ScanError returned along with
+// the others: the walk fails only if GitLab cannot be reached, rejects the token, or the group is not found.
+func (a *App) getGroupHierarchyWithGraphQL(ctx context.Context, group *gitlab.Group) ([]*gitlab.Group, []*gitlab.Project, error) {
+	scanErr := &ScanError{}
+	variables := map[string]any{"fullPath": group.FullPath}
+	descendants, nodeErrs, err := collectGraphQL[groupGQL](ctx, a, descendantGroupsQuery, variables, "descendantGroups")
+	if err != nil {
+		err = fmt.Errorf("failed to list subgroups for group %d: %w", group.ID, err)
+		if fatalGraphQLError(err) {
+			return nil, nil, err
+		}
+		// The projects of the group are listed anyway
+		scanErr.skip(dto.SourceKindGroup, group.ID, group.Path, err)
+	}
+	for _, nodeErr := range nodeErrs {
+		scanErr.skip(dto.SourceKindGroup, 0, "", nodeErr)
+	}
+	groups := make([]*gitlab.Group, 0, len(descendants)+1)
+	for _, g := range descendants {
+		if a.excluded(g.FullPath) {
+			continue
+		}
+		converted, err := g.convert()
+		if err != nil {
+			scanErr.skip(dto.SourceKindGroup, 0, g.FullPath, err)
+			continue
+		}
+		groups = append(groups, converted)
+	}
+	groups = append(groups, group)
+
+	variables["includeArchived"] = a.archived != ArchivedExclude
+	nodes, nodeErrs, err := collectGraphQL[projectGQL](ctx, a, groupProjectsQuery, variables, "projects")
+	if err != nil {
+		err = fmt.Errorf("failed to list projects for group %d: %w", group.ID, err)
+		if fatalGraphQLError(err) {
+			return nil, nil, err
+		}
+		scanErr.skip(dto.SourceKindGroup, group.ID, group.Path, err)
+	}
+	for _, nodeErr := range nodeErrs {
+		scanErr.skip(dto.SourceKindProject, 0, "", nodeErr)
+	}
+	projects := make([]*gitlab.Project, 0, len(nodes))
+	for _, p := range nodes {
+		if (a.archived == ArchivedOnly && !p.Archived) || a.excluded(p.FullPath) {
+			continue
+		}
+		converted, err := p.convert()
+		if err != nil {
+			scanErr.skip(dto.SourceKindProject, 0, p.FullPath, err)
+			continue
+		}
+		projects = append(projects, converted)
+	}
+	return groups, projects, scanErr.errOrNil()
+}
+
+// fatalGraphQLError returns true if the error of a query prevents the walk of the hierarchy:
+// GitLab cannot be reached or rejects the token, or the group is not found.
+func fatalGraphQLError(err error) bool {
+	if errors.Is(err, ErrGroupNotFound) {
+		return true
+	}
+	var responseErr *gitlab.GraphQLResponseError
+	if errors.As(err, &responseErr) {
+		err = responseErr.Err
+	}
+	var errResp *gitlab.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil {
+		return true
+	}
+	code := errResp.Response.StatusCode
+	return code == http.StatusUnauthorized || code == http.StatusForbidden
+}
+
+// collectGraphQL returns all the nodes of the connection field of the group, following the pages,
+// along with the errors reported on some nodes, which are not returned.
+// The variables must hold the full path of the group.
+func collectGraphQL[T any](ctx context.Context, a *App, query string, variables map[string]any,
+	field string,
+) ([]T, []error, error) {
+	var nodes []T
+	var nodeErrs []error
+	after := ""
+	for {
+		variables := maps.Clone(variables)
+		variables["first"] = graphQLPageSize
+		if after != "" {
+			variables["after"] = after
+		}
+		var response struct {
+			Data struct {
+				Group map[string]connectionGQL[*T] `json:"group"`
+			} `json:"data"`
+			Errors []errorGQL `json:"errors"`
+		}
+		_, err := a.gitlabClient.GraphQL.Do(gitlab.GraphQLQuery{Query: query, Variables: variables},
+			&response, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, nil, fmt.Errorf("GraphQL query failed: %w", err)
+		}
+		if response.Data.Group == nil {
+			if len(response.Errors) > 0 {
+				return nil, nil, fmt.Errorf("%w: %s: %s", ErrGroupNotFound, variables["fullPath"], response.Errors[0].Message)
+			}
+			return nil, nil, fmt.Errorf("%w: %s", ErrGroupNotFound, variables["fullPath"])
+		}
+		page := response.Data.Group[field]
+		failed := make(map[int]bool)
+		for _, e := range response.Errors {
+			failed[e.node(field)] = true
+			nodeErrs = append(nodeErrs, e)
+		}
+		for i, node := range page.Nodes {
+			// The nodes with an error are null or partial
+			if node != nil && !failed[i] {
+				nodes = append(nodes, *node)
+			}
+		}
+		if !page.PageInfo.HasNextPage || page.PageInfo.EndCursor == "" {
+			return nodes, nodeErrs, nil
+		}
+		after = page.PageInfo.EndCursor
+	}
+}
+
+// errorGQL is an error reported by the GraphQL API along with the data, e.g. on a node the token cannot read.
+type errorGQL struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path"`
+}
+
+func (e errorGQL) Error() string {
+	path := make([]string, 0, len(e.Path))
+	for _, elem := range e.Path {
+		path = append(path, fmt.Sprint(elem))
+	}
+	return fmt.Sprintf("GraphQL error at %s: %s", strings.Join(path, "."), e.Message)
+}
+
+// node returns the index of the node of the connection field the error is about, or -1.
+func (e errorGQL) node(field string) int {
+	for i := 0; i+2 < len(e.Path); i++ {
+		if e.Path[i] != field || e.Path[i+1] != "nodes" {
+			continue
+		}
+		if index, ok := e.Path[i+2].(float64); ok {
+			return int(index)
+		}
+	}
+	return -1
+}
+
+// convert returns the group with the fields used by the REST API.
+func (g groupGQL) convert() (*gitlab.Group, error) {
+	id, err := parseGlobalID(g.ID)
+	if err != nil {
+		return nil, err
+	}
+	group := &gitlab.Group{ID: id, Name: g.Name, Path: g.Path, FullPath: g.FullPath, WebURL: g.WebURL}
+	if g.Parent != nil {
+		if group.ParentID, err = parseGlobalID(g.Parent.ID); err != nil {
+			return nil, err
+		}
+	}
+	return group, nil
+}
+
+// convert returns the project with the fields used by the REST API.
+func (p projectGQL) convert() (*gitlab.Project, error) {
+	id, err := parseGlobalID(p.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &gitlab.Project{
+		ID: id, Name: p.Name, Path: p.Path, PathWithNamespace: p.FullPath, WebURL: p.WebURL, Archived: p.Archived,
+	}, nil
+}
+
+// parseGlobalID returns the numeric ID of a GraphQL global ID (e.g. gid://gitlab/Group/42).
+func parseGlobalID(gid string) (int64, error) {
+	id, err := strconv.ParseInt(gid[strings.LastIndex(gid, "/")+1:], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid global ID %q: %w", gid, err)
+	}
+	return id, nil
+}
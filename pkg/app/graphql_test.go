@@ -0,0 +1,178 @@
+package app_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/app"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+const hierarchyFixtures = "testdata/hierarchy"
+
+// hierarchyServer returns a server answering with the recorded fixtures of a group hierarchy.
+// A REST path is answered with the fixture named after it (e.g. groups/1/projects with
+// rest/groups_1_projects.json), or with an empty list if there is none.
+func hierarchyServer(t *testing.T, requests *atomic.Int32) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		name := strings.ReplaceAll(strings.TrimPrefix(r.URL.Path, "/api/v4/"), "/", "_") + ".json"
+		data, err := os.ReadFile(filepath.Join(hierarchyFixtures, "rest", name))
+		if err != nil {
+			data = []byte(`[]`)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	})
+	mux.HandleFunc("POST /api/graphql", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		var query struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&query); err != nil || query.Variables["fullPath"] != "acme" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		name := "descendant_groups.json"
+		if strings.Contains(query.Query, "projects(") {
+			name = "projects_page_1.json"
+			if query.Variables["after"] != nil {
+				name = "projects_page_2.json"
+			}
+		}
+		data, err := os.ReadFile(filepath.Join(hierarchyFixtures, "graphql", name))
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestApp_GetGroupHierarchy_GraphQLEquivalence(t *testing.T) {
+	var restRequests, graphQLRequests atomic.Int32
+	restServer := hierarchyServer(t, &restRequests)
+	graphQLServer := hierarchyServer(t, &graphQLRequests)
+
+	scan := func(server *httptest.Server, api string) ([]string, []string, []string) {
+		a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL), app.WithAPI(api))
+		group, err := a.GetGroup(1)
+		require.NoError(t, err)
+		groups, projects, err := a.GetGroupHierarchy(t.Context(), group)
+		require.NoError(t, err)
+		groupTokens, err := a.GetTokensOfGroups(t.Context(), groups)
+		require.NoError(t, err)
+		projectTokens, err := a.GetTokensOfProjects(t.Context(), projects)
+		require.NoError(t, err)
+
+		var groupPaths, projectPaths, tokens []string
+		for _, g := range groups {
+			data, err := json.Marshal(map[string]any{
+				"id": g.ID, "path": g.Path, "full_path": g.FullPath, "parent_id": g.ParentID, "web_url": g.WebURL,
+			})
+			require.NoError(t, err)
+			groupPaths = append(groupPaths, string(data))
+		}
+		for _, p := range projects {
+			data, err := json.Marshal(map[string]any{
+				"id": p.ID, "path_with_namespace": p.PathWithNamespace, "web_url": p.WebURL,
+			})
+			require.NoError(t, err)
+			projectPaths = append(projectPaths, string(data))
+		}
+		for _, token := range append(groupTokens, projectTokens...) {
+			data, err := json.Marshal(token)
+			require.NoError(t, err)
+			tokens = append(tokens, string(data))
+		}
+		return groupPaths, projectPaths, tokens
+	}
+
+	restGroups, restProjects, restTokens := scan(restServer, app.RESTAPI)
+	graphQLGroups, graphQLProjects, graphQLTokens := scan(graphQLServer, app.GraphQLAPI)
+
+	// The grandchild group acme/backend/internal and its project are included,
+	// and the project shared with the top-level group is listed once
+	assert.Len(t, restGroups, 4)
+	assert.Len(t, restProjects, 4)
+	assert.Len(t, restTokens, 7)
+	assert.ElementsMatch(t, restGroups, graphQLGroups)
+	assert.ElementsMatch(t, restProjects, graphQLProjects)
+	assert.ElementsMatch(t, restTokens, graphQLTokens)
+	assert.Less(t, graphQLRequests.Load(), restRequests.Load())
+}
+
+func TestApp_GetGroupHierarchy_GraphQLGroupNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data": {"group": null}}`))
+	}))
+	defer server.Close()
+
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL), app.WithAPI(app.GraphQLAPI))
+	_, _, err := a.GetGroupHierarchy(t.Context(), &gitlab.Group{ID: 1, FullPath: "missing"})
+	require.ErrorIs(t, err, app.ErrGroupNotFound)
+}
+
+func TestApp_GetGroupHierarchy_GraphQLPartialFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var query struct {
+			Query string `json:"query"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&query))
+		if !strings.Contains(query.Query, "projects(") {
+			// The subgroups cannot be listed, the projects of the group are listed anyway
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		// The second project cannot be read
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"data": {"group": {"projects": {"pageInfo": {"hasNextPage": false}, "nodes": [
+				{"id": "gid://gitlab/Project/10", "fullPath": "acme/api"}, null]}}},
+			"errors": [{"message": "Internal server error", "path": ["group", "projects", "nodes", 1]}]
+		}`))
+	}))
+	defer server.Close()
+
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL), app.WithAPI(app.GraphQLAPI))
+	groups, projects, err := a.GetGroupHierarchy(t.Context(), &gitlab.Group{ID: 1, Path: "acme", FullPath: "acme"})
+
+	var scanErr *app.ScanError
+	require.ErrorAs(t, err, &scanErr)
+	require.Len(t, scanErr.Skipped, 2)
+	assert.Equal(t, int64(1), scanErr.Skipped[0].SourceID)
+	assert.Contains(t, scanErr.Skipped[1].String(), "group.projects.nodes.1")
+	require.Len(t, groups, 1)
+	require.Len(t, projects, 1)
+	assert.Equal(t, "acme/api", projects[0].PathWithNamespace)
+}
+
+func TestApp_GetGroupHierarchy_GraphQLUnauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL), app.WithAPI(app.GraphQLAPI))
+	_, _, err := a.GetGroupHierarchy(t.Context(), &gitlab.Group{ID: 1, FullPath: "acme"})
+
+	require.Error(t, err)
+	var scanErr *app.ScanError
+	assert.NotErrorAs(t, err, &scanErr, "the walk fails")
+}
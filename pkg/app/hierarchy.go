@@ -0,0 +1,180 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// Handling of the archived projects.
+const (
+	ArchivedInclude = "include"
+	ArchivedExclude = "exclude"
+	ArchivedOnly    = "only"
+)
+
+// WithArchived sets the handling of the archived projects in the hierarchy of a group:
+// ArchivedInclude (default), ArchivedExclude or ArchivedOnly.
+func WithArchived(archived string) Option {
+	return func(a *App) {
+		a.archived = archived
+	}
+}
+
+// WithSharedProjects includes the projects shared with the groups of the hierarchy,
+// which belong to other groups. They are excluded by default.
+func WithSharedProjects(sharedProjects bool) Option {
+	return func(a *App) {
+		a.sharedProjects = sharedProjects
+	}
+}
+
+// WithoutForks skips the forks in the hierarchy of a group.
+func WithoutForks(skipForks bool) Option {
+	return func(a *App) {
+		a.skipForks = skipForks
+	}
+}
+
+// WithExcludedPaths excludes from the hierarchy of a group the subgroups and projects whose
+// full path, or the full path of one of their parent groups, matches one of the glob patterns
+// (e.g. "acme/legacy" or "acme/*/sandbox"). The patterns use the syntax of path.Match.
+func WithExcludedPaths(patterns []string) Option {
+	return func(a *App) {
+		a.excludedPaths = patterns
+	}
+}
+
+// GetGroupHierarchy returns the group along with its descendant groups, and the projects
+// of the group and of its descendant groups. If some groups or projects cannot be retrieved
+// with the REST API, the others are returned along with a *ScanError listing the skipped groups.
+func (a *App) GetGroupHierarchy(ctx context.Context, group *gitlab.Group) ([]*gitlab.Group, []*gitlab.Project, error) {
+	if err := a.checkClient(); err != nil {
+		return nil, nil, err
+	}
+	if a.api == GraphQLAPI {
+		return a.getGroupHierarchyWithGraphQL(ctx, group)
+	}
+	groups, err := a.GetDescendantGroups(group.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	groups = append(groups, group)
+	projects, err := a.getProjectsOfGroups(groups)
+	return groups, projects, err
+}
+
+// GetDescendantGroups returns all the descendant groups of the group that matches the given ID:
+// its subgroups, their subgroups, and so on. Each group is returned once. The groups under an
+// excluded path are not returned.
+func (a *App) GetDescendantGroups(groupID int64) ([]*gitlab.Group, error) {
+	if err := a.checkClient(); err != nil {
+		return nil, err
+	}
+	descendants, err := gitlab.ScanAndCollect(func(p gitlab.PaginationOptionFunc) ([]*gitlab.Group, *gitlab.Response, error) {
+		return a.gitlabClient.Groups.ListDescendantGroups(groupID, nil, p)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list descendant groups for group %d: %w", groupID, err)
+	}
+	groups := make([]*gitlab.Group, 0, len(descendants))
+	seen := map[int64]bool{groupID: true}
+	for _, group := range descendants {
+		if seen[group.ID] || a.excluded(group.FullPath) {
+			continue
+		}
+		seen[group.ID] = true
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// GetRecursiveProjectsOfGroup returns the projects of the group that matches the given ID
+// and of all its descendant groups. If the projects of some groups cannot be retrieved, the other
+// projects are returned along with a *ScanError listing the skipped groups.
+func (a *App) GetRecursiveProjectsOfGroup(groupID int64) ([]*gitlab.Project, error) {
+	if err := a.checkClient(); err != nil {
+		return nil, err
+	}
+	scanErr := &ScanError{}
+	groups := []*gitlab.Group{{ID: groupID}}
+	descendants, err := a.GetDescendantGroups(groupID)
+	if err != nil {
+		// The projects of the group are listed anyway
+		scanErr.skip(dto.SourceKindGroup, groupID, "", err)
+	}
+	projects, err := a.getProjectsOfGroups(append(groups, descendants...))
+	scanErr.merge(err)
+	return projects, scanErr.errOrNil()
+}
+
+// getProjectsOfGroups returns the projects of the groups. A project listed in several groups
+// is returned once. The archived and shared projects are filtered by the API, the forks and
+// the projects under an excluded path are skipped. If the projects of some groups cannot be retrieved, the other projects
+// are returned along with a *ScanError listing the skipped groups.
+func (a *App) getProjectsOfGroups(groups []*gitlab.Group) ([]*gitlab.Project, error) {
+	var projects []*gitlab.Project
+	scanErr := &ScanError{}
+	seen := make(map[int64]bool)
+	for _, group := range groups {
+		groupProjects, err := gitlab.ScanAndCollect(func(p gitlab.PaginationOptionFunc) ([]*gitlab.Project, *gitlab.Response, error) {
+			return a.gitlabClient.Groups.ListGroupProjects(group.ID, a.listGroupProjectsOptions(), p)
+		})
+		if err != nil {
+			scanErr.skip(dto.SourceKindGroup, group.ID, group.Path,
+				fmt.Errorf("failed to list projects for group %d: %w", group.ID, err))
+			continue
+		}
+		for _, project := range groupProjects {
+			if seen[project.ID] || !a.keepProject(project) {
+				continue
+			}
+			seen[project.ID] = true
+			projects = append(projects, project)
+		}
+	}
+	return projects, scanErr.errOrNil()
+}
+
+// listGroupProjectsOptions returns the options listing the projects of a group
+// according to the handling of the archived and shared projects.
+func (a *App) listGroupProjectsOptions() *gitlab.ListGroupProjectsOptions {
+	opt := &gitlab.ListGroupProjectsOptions{WithShared: gitlab.Ptr(a.sharedProjects)}
+	switch a.archived {
+	case ArchivedExclude:
+		opt.Archived = gitlab.Ptr(false)
+	case ArchivedOnly:
+		opt.Archived = gitlab.Ptr(true)
+	}
+	return opt
+}
+
+// keepProject returns false if the project is a fork to skip or is under an excluded path.
+func (a *App) keepProject(project *gitlab.Project) bool {
+	if a.skipForks && project.ForkedFromProject != nil {
+		return false
+	}
+	return !a.excluded(project.PathWithNamespace)
+}
+
+// excluded returns true if the full path, or the full path of one of its parent groups,
+// matches one of the excluded patterns.
+func (a *App) excluded(fullPath string) bool {
+	if len(a.excludedPaths) == 0 || fullPath == "" {
+		return false
+	}
+	segments := strings.Split(fullPath, "/")
+	for i := range segments {
+		prefix := strings.Join(segments[:i+1], "/")
+		for _, pattern := range a.excludedPaths {
+			if matched, _ := path.Match(pattern, prefix); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
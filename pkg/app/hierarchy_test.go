@@ -0,0 +1,120 @@
+package app_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/app"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestApp_GetDescendantGroups_Pagination(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/groups/1/descendant_groups", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			// The grandchild group is listed again on the last page
+			fmt.Fprint(w, `[{"id": 3, "path": "grandchild", "parent_id": 2}, {"id": 2, "path": "child", "parent_id": 1}]`)
+			return
+		}
+		w.Header().Set("X-Next-Page", "2")
+		fmt.Fprint(w, `[{"id": 2, "path": "child", "parent_id": 1}]`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL))
+	groups, err := a.GetDescendantGroups(1)
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	assert.Equal(t, "child", groups[0].Path)
+	assert.Equal(t, "grandchild", groups[1].Path)
+}
+
+func TestApp_GetGroupHierarchy_ProjectOptions(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         []app.Option
+		wantQuery    string
+		wantGroups   []string
+		wantProjects []string
+	}{
+		{
+			name:         "default",
+			wantQuery:    "with_shared=false",
+			wantGroups:   []string{"acme/legacy", "acme/legacy/old", "acme/web", "acme"},
+			wantProjects: []string{"acme/site", "acme/site-fork", "acme/legacy/app", "acme/legacy/old/app", "acme/web/app"},
+		},
+		{
+			name:         "archived excluded and shared included",
+			opts:         []app.Option{app.WithArchived(app.ArchivedExclude), app.WithSharedProjects(true)},
+			wantQuery:    "archived=false&with_shared=true",
+			wantGroups:   []string{"acme/legacy", "acme/legacy/old", "acme/web", "acme"},
+			wantProjects: []string{"acme/site", "acme/site-fork", "acme/legacy/app", "acme/legacy/old/app", "acme/web/app"},
+		},
+		{
+			name:         "archived only",
+			opts:         []app.Option{app.WithArchived(app.ArchivedOnly)},
+			wantQuery:    "archived=true&with_shared=false",
+			wantGroups:   []string{"acme/legacy", "acme/legacy/old", "acme/web", "acme"},
+			wantProjects: []string{"acme/site", "acme/site-fork", "acme/legacy/app", "acme/legacy/old/app", "acme/web/app"},
+		},
+		{
+			name:         "forks skipped and subtree excluded",
+			opts:         []app.Option{app.WithoutForks(true), app.WithExcludedPaths([]string{"acme/leg*"})},
+			wantQuery:    "with_shared=false",
+			wantGroups:   []string{"acme/web", "acme"},
+			wantProjects: []string{"acme/site", "acme/web/app"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/api/v4/groups/1/descendant_groups", func(w http.ResponseWriter, _ *http.Request) {
+				fmt.Fprint(w, `[
+					{"id": 2, "path": "legacy", "full_path": "acme/legacy"},
+					{"id": 3, "path": "old", "full_path": "acme/legacy/old"},
+					{"id": 4, "path": "web", "full_path": "acme/web"}
+				]`)
+			})
+			var queries []string
+			projects := map[string]string{
+				"1": `[{"id": 10, "path_with_namespace": "acme/site"},
+					{"id": 11, "path_with_namespace": "acme/site-fork", "forked_from_project": {"id": 10}}]`,
+				"2": `[{"id": 20, "path_with_namespace": "acme/legacy/app"}]`,
+				"3": `[{"id": 30, "path_with_namespace": "acme/legacy/old/app"}]`,
+				"4": `[{"id": 40, "path_with_namespace": "acme/web/app"}]`,
+			}
+			mux.HandleFunc("/api/v4/groups/{id}/projects", func(w http.ResponseWriter, r *http.Request) {
+				query := r.URL.Query()
+				query.Del("page")
+				query.Del("per_page")
+				queries = append(queries, query.Encode())
+				fmt.Fprint(w, projects[r.PathValue("id")])
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			a := app.NewApp(&MockRenderer{}, append([]app.Option{app.WithGitlabEndpoint(server.URL)}, tt.opts...)...)
+			groups, projectsOfGroups, err := a.GetGroupHierarchy(t.Context(), &gitlab.Group{ID: 1, FullPath: "acme"})
+			require.NoError(t, err)
+
+			var groupPaths, projectPaths []string
+			for _, g := range groups {
+				groupPaths = append(groupPaths, g.FullPath)
+			}
+			for _, p := range projectsOfGroups {
+				projectPaths = append(projectPaths, p.PathWithNamespace)
+			}
+			assert.Equal(t, tt.wantGroups, groupPaths)
+			assert.ElementsMatch(t, tt.wantProjects, projectPaths)
+			require.NotEmpty(t, queries)
+			for _, query := range queries {
+				assert.Equal(t, tt.wantQuery, query)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// Paths of the settings pages of the hooks and integrations.
+const (
+	hooksSettingsPath        = "/-/hooks"
+	integrationsSettingsPath = "/-/settings/integrations/"
+)
+
+// WithInventory includes the hooks and the active integrations of the groups and projects
+// in their tokens. Their credentials (webhook secret tokens, integration API keys) cannot expire.
+// All the hooks are included: the API does not tell whether a hook has a secret token.
+func WithInventory(inventory bool) Option {
+	return func(a *App) {
+		a.inventory = inventory
+	}
+}
+
+// getCredentialsInventoryOfProject returns the hooks and the active integrations of a project.
+func (a *App) getCredentialsInventoryOfProject(project *gitlab.Project) ([]dto.Token, error) {
+	hooks, err := gitlab.ScanAndCollect(func(p gitlab.PaginationOptionFunc) ([]*gitlab.ProjectHook, *gitlab.Response, error) {
+		return a.gitlabClient.Projects.ListProjectHooks(project.ID, nil, p)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hooks for project %d: %w", project.ID, err)
+	}
+	tokens := make([]dto.Token, 0, len(hooks))
+	for _, hook := range hooks {
+		token := ConvertWebhookToDTOToken(hook.ID, hook.Name, hook.URL, hook.CreatedAt)
+		token.SourceKind = dto.SourceKindProject
+		token.WebURL = project.WebURL + hooksSettingsPath
+		tokens = append(tokens, token)
+	}
+
+	integrations, _, err := a.gitlabClient.Services.ListServices(project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list integrations for project %d: %w", project.ID, err)
+	}
+	for _, integration := range integrations {
+		if !integration.Active {
+			continue
+		}
+		token := ConvertIntegrationToDTOToken(integration)
+		token.SourceKind = dto.SourceKindProject
+		token.WebURL = project.WebURL + integrationsSettingsPath + integration.Slug + "/edit"
+		tokens = append(tokens, token)
+	}
+
+	for i := range tokens {
+		tokens[i].Source = project.PathWithNamespace
+		tokens[i].SourceID = project.ID
+	}
+	return tokens, nil
+}
+
+// getCredentialsInventoryOfGroup returns the hooks and the active integrations of a group.
+func (a *App) getCredentialsInventoryOfGroup(group *gitlab.Group) ([]dto.Token, error) {
+	hooks, err := gitlab.ScanAndCollect(func(p gitlab.PaginationOptionFunc) ([]*gitlab.GroupHook, *gitlab.Response, error) {
+		return a.gitlabClient.Groups.ListGroupHooks(group.ID, nil, p)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hooks for group %d: %w", group.ID, err)
+	}
+	tokens := make([]dto.Token, 0, len(hooks))
+	for _, hook := range hooks {
+		token := ConvertWebhookToDTOToken(hook.ID, hook.Name, hook.URL, hook.CreatedAt)
+		token.SourceKind = dto.SourceKindGroup
+		token.WebURL = group.WebURL + hooksSettingsPath
+		tokens = append(tokens, token)
+	}
+
+	integrations, err := gitlab.ScanAndCollect(func(p gitlab.PaginationOptionFunc) ([]*gitlab.Integration, *gitlab.Response, error) {
+		return a.gitlabClient.Integrations.ListActiveGroupIntegrations(group.ID, nil, p)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list integrations for group %d: %w", group.ID, err)
+	}
+	for _, integration := range integrations {
+		token := ConvertIntegrationToDTOToken(integration)
+		token.SourceKind = dto.SourceKindGroup
+		token.WebURL = group.WebURL + integrationsSettingsPath + integration.Slug + "/edit"
+		tokens = append(tokens, token)
+	}
+
+	for i := range tokens {
+		tokens[i].Source = group.Path
+		tokens[i].SourceID = group.ID
+	}
+	return tokens, nil
+}
@@ -0,0 +1,121 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// Paths of the settings pages of the keys.
+const (
+	deployKeysSettingsPath         = "/-/settings/repository#js-deploy-keys-settings"
+	instanceDeployKeysSettingsPath = "/admin/deploy_keys"
+	sshKeysSettingsPath            = "/-/user_settings/ssh_keys"
+)
+
+// getDeployKeysOfProject returns the deploy keys of a project.
+func (a *App) getDeployKeysOfProject(project *gitlab.Project) ([]dto.Token, error) {
+	deployKeys, err := gitlab.ScanAndCollect(func(p gitlab.PaginationOptionFunc) ([]*gitlab.ProjectDeployKey, *gitlab.Response, error) {
+		return a.gitlabClient.DeployKeys.ListProjectDeployKeys(project.ID, nil, p)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deploy keys for project %d: %w", project.ID, err)
+	}
+	tokens := ConvertProjectDeployKeyToDTOTokens(deployKeys)
+	// Add the source
+	for i := range tokens {
+		tokens[i].Source = project.PathWithNamespace
+		tokens[i].SourceID = project.ID
+		tokens[i].WebURL = project.WebURL + deployKeysSettingsPath
+	}
+	return tokens, nil
+}
+
+// GetInstanceDeployKeys returns the deploy keys of the instance. It requires administrator access.
+func (a *App) GetInstanceDeployKeys(_ context.Context) ([]dto.Token, error) {
+	if err := a.checkClient(); err != nil {
+		return nil, err
+	}
+	deployKeys, err := gitlab.ScanAndCollect(func(p gitlab.PaginationOptionFunc) ([]*gitlab.InstanceDeployKey, *gitlab.Response, error) {
+		return a.gitlabClient.DeployKeys.ListAllDeployKeys(nil, p)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list instance deploy keys: %w", err)
+	}
+	tokens := ConvertInstanceDeployKeyToDTOTokens(deployKeys)
+	for i := range tokens {
+		tokens[i].WebURL = a.webURL() + instanceDeployKeysSettingsPath
+	}
+	return tokens, nil
+}
+
+// GetSSHKeys returns the SSH keys of the current user.
+func (a *App) GetSSHKeys(_ context.Context) ([]dto.Token, error) {
+	if err := a.checkClient(); err != nil {
+		return nil, err
+	}
+	sshKeys, err := gitlab.ScanAndCollect(func(p gitlab.PaginationOptionFunc) ([]*gitlab.SSHKey, *gitlab.Response, error) {
+		return a.gitlabClient.Users.ListSSHKeys(nil, p)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SSH keys: %w", err)
+	}
+	tokens := ConvertSSHKeyToDTOTokens(sshKeys)
+	for i := range tokens {
+		tokens[i].WebURL = a.webURL() + sshKeysSettingsPath
+	}
+	return tokens, nil
+}
+
+// GetSSHKeysOfAllUsers returns the SSH keys of all the active users. It requires administrator access.
+// If the keys of some users cannot be retrieved, the other keys are returned along with a *ScanError
+// listing the skipped users.
+func (a *App) GetSSHKeysOfAllUsers(ctx context.Context) ([]dto.Token, error) {
+	if err := a.checkClient(); err != nil {
+		return nil, err
+	}
+	users, err := a.getActiveUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var tokens []dto.Token
+	scanErr := &ScanError{}
+	for _, user := range users {
+		sshKeys, err := gitlab.ScanAndCollect(func(p gitlab.PaginationOptionFunc) ([]*gitlab.SSHKey, *gitlab.Response, error) {
+			return a.gitlabClient.Users.ListSSHKeysForUser(user.ID, nil, p)
+		})
+		if err != nil {
+			scanErr.skip(dto.SourceKindUser, user.ID, user.Username,
+				fmt.Errorf("failed to list SSH keys of user %s: %w", user.Username, err))
+			a.log.Info("SSH keys of user skipped", "user", user.Username, "error", err)
+			continue
+		}
+		dtoTokens := ConvertSSHKeyToDTOTokens(sshKeys)
+		// Add the source
+		for i := range dtoTokens {
+			dtoTokens[i].Source = user.Username
+			dtoTokens[i].SourceID = user.ID
+			dtoTokens[i].Owner = user.Username
+			dtoTokens[i].WebURL = user.WebURL
+		}
+		tokens = append(tokens, dtoTokens...)
+	}
+	return tokens, scanErr.errOrNil()
+}
+
+// getActiveUsers returns the active human users of the instance.
+func (a *App) getActiveUsers(_ context.Context) ([]*gitlab.User, error) {
+	opt := &gitlab.ListUsersOptions{
+		Active: gitlab.Ptr(true),
+		Humans: gitlab.Ptr(true),
+	}
+	users, err := gitlab.ScanAndCollect(func(p gitlab.PaginationOptionFunc) ([]*gitlab.User, *gitlab.Response, error) {
+		return a.gitlabClient.Users.ListUsers(opt, p)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	return users, nil
+}
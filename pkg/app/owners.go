@@ -0,0 +1,199 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// botUsernameRegexp matches the usernames of the bot users created for group and project access tokens.
+var botUsernameRegexp = regexp.MustCompile(`^(group|project)_\d+_bot`)
+
+// member is a member of a group or a project.
+type member struct {
+	ID          int64
+	Username    string
+	Name        string
+	Email       string
+	AccessLevel gitlab.AccessLevelValue
+	CreatedBy   *gitlab.MemberCreatedBy
+}
+
+// ownerCache caches the members and users retrieved to resolve the owners of the tokens,
+// so that a scan does not request the same members or user twice.
+type ownerCache struct {
+	mu      sync.Mutex
+	members map[string][]member
+	users   map[int64]*gitlab.User
+}
+
+func newOwnerCache() *ownerCache {
+	return &ownerCache{
+		members: make(map[string][]member),
+		users:   make(map[int64]*gitlab.User),
+	}
+}
+
+// WithOwners enables the resolution of the owners of the tokens.
+func WithOwners(resolveOwners bool) Option {
+	return func(a *App) {
+		a.resolveOwners = resolveOwners
+	}
+}
+
+// groupMembers returns the members of a group, including inherited members.
+func (a *App) groupMembers(groupID int64) ([]member, error) {
+	key := fmt.Sprintf("group:%d", groupID)
+	if members, ok := a.owners.getMembers(key); ok {
+		return members, nil
+	}
+	groupMembers, err := gitlab.ScanAndCollect(func(p gitlab.PaginationOptionFunc) ([]*gitlab.GroupMember, *gitlab.Response, error) {
+		return a.gitlabClient.Groups.ListAllGroupMembers(groupID, nil, p)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members of group %d: %w", groupID, err)
+	}
+	members := make([]member, 0, len(groupMembers))
+	for _, m := range groupMembers {
+		email := m.Email
+		if email == "" {
+			email = m.PublicEmail
+		}
+		members = append(members, member{
+			ID:          m.ID,
+			Username:    m.Username,
+			Name:        m.Name,
+			Email:       email,
+			AccessLevel: m.AccessLevel,
+			CreatedBy:   m.CreatedBy,
+		})
+	}
+	a.owners.setMembers(key, members)
+	return members, nil
+}
+
+// projectMembers returns the members of a project, including inherited members.
+func (a *App) projectMembers(projectID int64) ([]member, error) {
+	key := fmt.Sprintf("project:%d", projectID)
+	if members, ok := a.owners.getMembers(key); ok {
+		return members, nil
+	}
+	projectMembers, err := gitlab.ScanAndCollect(func(p gitlab.PaginationOptionFunc) ([]*gitlab.ProjectMember, *gitlab.Response, error) {
+		return a.gitlabClient.ProjectMembers.ListAllProjectMembers(projectID, nil, p)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members of project %d: %w", projectID, err)
+	}
+	members := make([]member, 0, len(projectMembers))
+	for _, m := range projectMembers {
+		members = append(members, member{
+			ID:          m.ID,
+			Username:    m.Username,
+			Name:        m.Name,
+			Email:       m.Email,
+			AccessLevel: m.AccessLevel,
+			CreatedBy:   m.CreatedBy,
+		})
+	}
+	a.owners.setMembers(key, members)
+	return members, nil
+}
+
+// user returns the user that matches the given ID.
+func (a *App) user(userID int64) (*gitlab.User, error) {
+	if u, ok := a.owners.getUser(userID); ok {
+		return u, nil
+	}
+	u, _, err := a.gitlabClient.Users.GetUser(userID, gitlab.GetUsersOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user %d: %w", userID, err)
+	}
+	a.owners.setUser(userID, u)
+	return u, nil
+}
+
+// setResourceOwners sets the owners of group or project tokens.
+// botUserIDs holds the ID of the bot user of each token, or 0 if the token has no bot user (deploy tokens).
+// The contacts are the members with the Owner or Maintainer role. The owner is the user who created
+// the bot user of the token when GitLab provides it.
+func setResourceOwners(tokens []dto.Token, botUserIDs []int64, members []member) {
+	contacts := maintainerContacts(members)
+	for i := range tokens {
+		tokens[i].Contacts = contacts
+		if i >= len(botUserIDs) || botUserIDs[i] == 0 {
+			continue
+		}
+		for _, m := range members {
+			if m.ID == botUserIDs[i] && m.CreatedBy != nil {
+				tokens[i].Owner = m.CreatedBy.Username
+				break
+			}
+		}
+	}
+}
+
+// maintainerContacts returns the members with at least the Maintainer role, except bot users.
+func maintainerContacts(members []member) []dto.Contact {
+	var contacts []dto.Contact
+	for _, m := range members {
+		if m.AccessLevel < gitlab.MaintainerPermissions || botUsernameRegexp.MatchString(m.Username) {
+			continue
+		}
+		contacts = append(contacts, dto.Contact{
+			Username: m.Username,
+			Name:     m.Name,
+			Email:    m.Email,
+		})
+	}
+	return contacts
+}
+
+// setPersonalOwners sets the owner of personal access tokens to the user they belong to.
+// The tokens whose user cannot be retrieved, e.g. deleted or blocked, are left without owner.
+func (a *App) setPersonalOwners(tokens []dto.Token, pats []*gitlab.PersonalAccessToken) {
+	for i := range tokens {
+		if i >= len(pats) || pats[i].UserID == 0 {
+			continue
+		}
+		u, err := a.user(pats[i].UserID)
+		if err != nil {
+			a.log.Warn("owner of personal access token not resolved", "token", tokens[i].Name, "error", err)
+			continue
+		}
+		email := u.Email
+		if email == "" {
+			email = u.PublicEmail
+		}
+		tokens[i].Owner = u.Username
+		tokens[i].Contacts = []dto.Contact{{Username: u.Username, Name: u.Name, Email: email}}
+	}
+}
+
+func (c *ownerCache) getMembers(key string) ([]member, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	members, ok := c.members[key]
+	return members, ok
+}
+
+func (c *ownerCache) setMembers(key string, members []member) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.members[key] = members
+}
+
+func (c *ownerCache) getUser(userID int64) (*gitlab.User, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u, ok := c.users[userID]
+	return u, ok
+}
+
+func (c *ownerCache) setUser(userID int64, u *gitlab.User) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.users[userID] = u
+}
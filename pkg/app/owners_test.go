@@ -0,0 +1,79 @@
+package app_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/app"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestApp_GetTokensOfProjects_Owners(t *testing.T) {
+	var membersCalls atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/1/access_tokens", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[{"id": 10, "name": "ci", "user_id": 100, "expires_at": "2030-01-01"}]`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/triggers", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[{"id": 20, "description": "deploy", "owner": {"id": 2, "username": "bob"}}]`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/deploy_keys", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/api/v4/projects/1/members/all", func(w http.ResponseWriter, _ *http.Request) {
+		membersCalls.Add(1)
+		fmt.Fprint(w, `[
+			{"id": 1, "username": "alice", "name": "Alice", "access_level": 50},
+			{"id": 2, "username": "bob", "name": "Bob", "access_level": 30},
+			{"id": 100, "username": "project_1_bot_abc", "access_level": 40,
+			 "created_by": {"id": 3, "username": "carol"}}
+		]`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL), app.WithOwners(true))
+	projects := []*gitlab.Project{{ID: 1, PathWithNamespace: "group/project"}}
+
+	tokens, err := a.GetTokensOfProjects(context.Background(), projects)
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+	assert.Equal(t, "carol", tokens[0].Owner)
+	require.Len(t, tokens[0].Contacts, 1)
+	assert.Equal(t, "alice", tokens[0].Contacts[0].Username)
+	assert.Equal(t, "bob", tokens[1].Owner)
+	assert.Equal(t, tokens[0].Contacts, tokens[1].Contacts)
+
+	// Members are cached between scans
+	_, err = a.GetTokensOfProjects(context.Background(), projects)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), membersCalls.Load())
+}
+
+func TestApp_GetPersonalAccessTokens_OwnerNotResolved(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/personal_access_tokens", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[{"id": 1, "name": "alice-token", "user_id": 1}, {"id": 2, "name": "deleted-token", "user_id": 2}]`)
+	})
+	mux.HandleFunc("/api/v4/users/1", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"id": 1, "username": "alice"}`)
+	})
+	// The user was deleted
+	mux.HandleFunc("/api/v4/users/2", http.NotFound)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL), app.WithOwners(true))
+	tokens, err := a.GetPersonalAccessTokens(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+	assert.Equal(t, "alice", tokens[0].Owner)
+	assert.Empty(t, tokens[1].Owner)
+}
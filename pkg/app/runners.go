@@ -0,0 +1,188 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// Types of runner.
+const (
+	instanceRunnerType = "instance_type"
+	groupRunnerType    = "group_type"
+	projectRunnerType  = "project_type"
+)
+
+// Paths of the settings pages of the runners.
+const (
+	runnersSettingsPath         = "/-/runners/"
+	instanceRunnersSettingsPath = "/admin/runners/"
+)
+
+// Runner represents a runner with its registration metadata.
+// The GitLab client does not decode the expiration date of the authentication token
+// and the creation metadata of runners, so they are added here.
+type Runner struct {
+	gitlab.RunnerDetails
+	TokenExpiresAt *time.Time        `json:"token_expires_at"`
+	CreatedAt      *time.Time        `json:"created_at"`
+	CreatedBy      *gitlab.BasicUser `json:"created_by"`
+}
+
+// WithRunners includes the runners of the groups and projects in their tokens.
+// It requires one additional API call per runner.
+func WithRunners(listRunners bool) Option {
+	return func(a *App) {
+		a.listRunners = listRunners
+	}
+}
+
+// getRunner returns the details of a runner. The expiration date of its token is taken
+// from the runner listed if the details do not contain it.
+func (a *App) getRunner(listed *gitlab.Runner) (*Runner, error) {
+	req, err := a.gitlabClient.NewRequest(http.MethodGet, fmt.Sprintf("runners/%d", listed.ID), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get runner %d: %w", listed.ID, err)
+	}
+	var runner Runner
+	if _, err := a.gitlabClient.Do(req, &runner); err != nil {
+		return nil, fmt.Errorf("failed to get runner %d: %w", listed.ID, err)
+	}
+	if runner.TokenExpiresAt == nil {
+		runner.TokenExpiresAt = listed.TokenExpiresAt
+	}
+	return &runner, nil
+}
+
+// GetInstanceRunners returns the instance runners. It requires administrator access.
+func (a *App) GetInstanceRunners(_ context.Context) ([]dto.Token, error) {
+	if err := a.checkClient(); err != nil {
+		return nil, err
+	}
+	runners, err := gitlab.ScanAndCollect(func(p gitlab.PaginationOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error) {
+		return a.gitlabClient.Runners.ListAllRunners(
+			&gitlab.ListRunnersOptions{Type: gitlab.Ptr(instanceRunnerType)}, p)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list instance runners: %w", err)
+	}
+	tokens := make([]dto.Token, 0, len(runners))
+	for _, r := range runners {
+		runner, err := a.getRunner(r)
+		if err != nil {
+			return nil, err
+		}
+		token := ConvertRunnerToDTOToken(runner)
+		token.WebURL = fmt.Sprintf("%s%s%d", a.webURL(), instanceRunnersSettingsPath, runner.ID)
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}
+
+// GetRunnersOfGroups returns the runners owned by the groups.
+// The runners inherited from a parent group are returned with the parent group only, and are
+// retrieved once whatever the number of subgroups inheriting them.
+// If the runners of some groups cannot be retrieved, the other runners are returned
+// along with a *ScanError listing the skipped groups.
+func (a *App) GetRunnersOfGroups(_ context.Context, groups []*gitlab.Group) ([]dto.Token, error) {
+	if err := a.checkClient(); err != nil {
+		return nil, err
+	}
+	var tokens []dto.Token
+	scanErr := &ScanError{}
+	seen := make(map[int64]bool)
+	details := make(map[int64]*Runner)
+	for _, group := range groups {
+		runners, err := gitlab.ScanAndCollect(func(p gitlab.PaginationOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error) {
+			return a.gitlabClient.Runners.ListGroupsRunners(group.ID,
+				&gitlab.ListGroupsRunnersOptions{Type: gitlab.Ptr(groupRunnerType)}, p)
+		})
+		if err != nil {
+			scanErr.skip(dto.SourceKindGroup, group.ID, group.Path,
+				fmt.Errorf("failed to list runners for group %d: %w", group.ID, err))
+			continue
+		}
+		for _, r := range runners {
+			if seen[r.ID] {
+				continue
+			}
+			runner, ok := details[r.ID]
+			if !ok {
+				runner, err = a.getRunner(r)
+				if err != nil {
+					seen[r.ID] = true
+					scanErr.skip(dto.SourceKindGroup, group.ID, group.Path, err)
+					continue
+				}
+				details[r.ID] = runner
+			}
+			if len(runner.Groups) > 0 && runner.Groups[0].ID != group.ID {
+				// Inherited from a parent group, returned with it, which may come later in the list
+				continue
+			}
+			seen[r.ID] = true
+			token := ConvertRunnerToDTOToken(runner)
+			token.Source = group.Path
+			token.SourceID = group.ID
+			token.WebURL = fmt.Sprintf("%s%s%d", group.WebURL, runnersSettingsPath, runner.ID)
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, scanErr.errOrNil()
+}
+
+// GetRunnersOfProjects returns the runners owned by the projects.
+// A runner assigned to several projects is returned with the project that registered it only,
+// and is retrieved once whatever the number of projects it is assigned to.
+// If the runners of some projects cannot be retrieved, the other runners are returned
+// along with a *ScanError listing the skipped projects.
+func (a *App) GetRunnersOfProjects(_ context.Context, projects []*gitlab.Project) ([]dto.Token, error) {
+	if err := a.checkClient(); err != nil {
+		return nil, err
+	}
+	var tokens []dto.Token
+	scanErr := &ScanError{}
+	seen := make(map[int64]bool)
+	details := make(map[int64]*Runner)
+	for _, project := range projects {
+		runners, err := gitlab.ScanAndCollect(func(p gitlab.PaginationOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error) {
+			return a.gitlabClient.Runners.ListProjectRunners(project.ID,
+				&gitlab.ListProjectRunnersOptions{Type: gitlab.Ptr(projectRunnerType)}, p)
+		})
+		if err != nil {
+			scanErr.skip(dto.SourceKindProject, project.ID, project.PathWithNamespace,
+				fmt.Errorf("failed to list runners for project %d: %w", project.ID, err))
+			continue
+		}
+		for _, r := range runners {
+			if seen[r.ID] {
+				continue
+			}
+			runner, ok := details[r.ID]
+			if !ok {
+				runner, err = a.getRunner(r)
+				if err != nil {
+					seen[r.ID] = true
+					scanErr.skip(dto.SourceKindProject, project.ID, project.PathWithNamespace, err)
+					continue
+				}
+				details[r.ID] = runner
+			}
+			if len(runner.Projects) > 0 && runner.Projects[0].ID != project.ID {
+				// Registered by another project, returned with it, which may come later in the list
+				continue
+			}
+			seen[r.ID] = true
+			token := ConvertRunnerToDTOToken(runner)
+			token.Source = project.PathWithNamespace
+			token.SourceID = project.ID
+			token.WebURL = fmt.Sprintf("%s%s%d", project.WebURL, runnersSettingsPath, runner.ID)
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, scanErr.errOrNil()
+}
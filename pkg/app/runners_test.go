@@ -0,0 +1,125 @@
+package app_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/app"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestApp_GetRunnersOfGroups(t *testing.T) {
+	mux := http.NewServeMux()
+	// The runners of the parent group are also listed in the subgroup
+	mux.HandleFunc("/api/v4/groups/1/runners", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "group_type", r.URL.Query().Get("type"))
+		fmt.Fprint(w, `[{"id": 5, "description": "shared", "token_expires_at": "2030-01-01T00:00:00Z"}]`)
+	})
+	mux.HandleFunc("/api/v4/groups/2/runners", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[
+			{"id": 5, "description": "shared", "token_expires_at": "2030-01-01T00:00:00Z"},
+			{"id": 6, "description": "build"}
+		]`)
+	})
+	runnerRequests := 0
+	mux.HandleFunc("/api/v4/runners/5", func(w http.ResponseWriter, _ *http.Request) {
+		runnerRequests++
+		fmt.Fprint(w, `{"id": 5, "description": "shared", "runner_type": "group_type",
+			"contacted_at": "2024-11-15T10:30:00Z", "created_at": "2024-01-02T00:00:00Z",
+			"created_by": {"id": 3, "username": "carol"}, "groups": [{"id": 1, "name": "parent"}]}`)
+	})
+	mux.HandleFunc("/api/v4/runners/6", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"id": 6, "description": "build", "runner_type": "group_type",
+			"token_expires_at": "2030-06-01T00:00:00Z", "groups": [{"id": 2, "name": "child"}]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL))
+	groups := []*gitlab.Group{
+		{ID: 2, Path: "child", FullPath: "parent/child", WebURL: "https://gitlab.example.com/groups/parent/child"},
+		{ID: 1, Path: "parent", FullPath: "parent", WebURL: "https://gitlab.example.com/groups/parent"},
+	}
+	tokens, err := a.GetRunnersOfGroups(context.Background(), groups)
+	require.NoError(t, err)
+	assert.Equal(t, 1, runnerRequests, "inherited runner retrieved once")
+
+	require.Len(t, tokens, 2)
+	assert.Equal(t, "build", tokens[0].Name)
+	assert.Equal(t, "child", tokens[0].Source)
+	assert.Equal(t, "2030-06-01", tokens[0].ExpiresAt)
+	assert.Equal(t, "https://gitlab.example.com/groups/parent/child/-/runners/6", tokens[0].WebURL)
+
+	assert.Equal(t, "shared", tokens[1].Name)
+	assert.Equal(t, "parent", tokens[1].Source)
+	assert.Equal(t, int64(1), tokens[1].SourceID)
+	assert.Equal(t, dto.SourceKindGroup, tokens[1].SourceKind)
+	assert.Equal(t, dto.TypeRunnerToken, tokens[1].Type)
+	assert.Equal(t, "2030-01-01", tokens[1].ExpiresAt, "expiration date taken from the list")
+	assert.Equal(t, "2024-01-02", tokens[1].CreatedAt)
+	assert.Equal(t, "2024-11-15", tokens[1].LastUsedAt)
+	assert.Equal(t, "carol", tokens[1].Owner)
+}
+
+func TestApp_GetRunnersOfProjects_Shared(t *testing.T) {
+	mux := http.NewServeMux()
+	// The runner registered by project 1 is assigned to the other projects
+	for _, id := range []string{"1", "2", "3"} {
+		mux.HandleFunc("/api/v4/projects/"+id+"/runners", func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, `[{"id": 7}]`)
+		})
+	}
+	runnerRequests := 0
+	mux.HandleFunc("/api/v4/runners/7", func(w http.ResponseWriter, _ *http.Request) {
+		runnerRequests++
+		fmt.Fprint(w, `{"id": 7, "runner_type": "project_type", "projects": [{"id": 1}, {"id": 2}, {"id": 3}]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL))
+	projects := []*gitlab.Project{
+		{ID: 3, PathWithNamespace: "group/c"}, {ID: 2, PathWithNamespace: "group/b"}, {ID: 1, PathWithNamespace: "group/a"},
+	}
+	tokens, err := a.GetRunnersOfProjects(context.Background(), projects)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, runnerRequests, "shared runner retrieved once")
+	require.Len(t, tokens, 1)
+	assert.Equal(t, "group/a", tokens[0].Source)
+}
+
+func TestApp_GetTokensOfProjects_WithRunners(t *testing.T) {
+	mux := http.NewServeMux()
+	for _, path := range []string{"access_tokens", "triggers", "deploy_keys"} {
+		mux.HandleFunc("/api/v4/projects/1/"+path, func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, `[]`)
+		})
+	}
+	mux.HandleFunc("/api/v4/projects/1/runners", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "project_type", r.URL.Query().Get("type"))
+		fmt.Fprint(w, `[{"id": 7}]`)
+	})
+	mux.HandleFunc("/api/v4/runners/7", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"id": 7, "runner_type": "project_type", "projects": [{"id": 1}]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL), app.WithRunners(true))
+	project := &gitlab.Project{ID: 1, PathWithNamespace: "group/project", WebURL: "https://gitlab.example.com/group/project"}
+	tokens, err := a.GetTokensOfProjects(context.Background(), []*gitlab.Project{project})
+	require.NoError(t, err)
+
+	require.Len(t, tokens, 1)
+	assert.Equal(t, "runner 7", tokens[0].Name)
+	assert.Equal(t, "group/project", tokens[0].Source)
+	assert.Equal(t, dto.SourceKindProject, tokens[0].SourceKind)
+	assert.Equal(t, "", tokens[0].ExpiresAt)
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// serviceAccountsSettingsPath is the path of the settings page of the service accounts of a group.
+const serviceAccountsSettingsPath = "/-/settings/service_accounts"
+
+// WithServiceAccounts includes the personal access tokens of the service accounts of the
+// top-level groups in the tokens of the groups.
+func WithServiceAccounts(listServiceAccounts bool) Option {
+	return func(a *App) {
+		a.listServiceAccounts = listServiceAccounts
+	}
+}
+
+// getServiceAccountTokensOfGroup returns the personal access tokens of the service accounts of a group.
+// Service accounts belong to top-level groups only.
+func (a *App) getServiceAccountTokensOfGroup(group *gitlab.Group) ([]dto.Token, error) {
+	serviceAccounts, err := gitlab.ScanAndCollect(func(p gitlab.PaginationOptionFunc) ([]*gitlab.GroupServiceAccount, *gitlab.Response, error) {
+		return a.gitlabClient.Groups.ListServiceAccounts(group.ID, nil, p)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service accounts for group %d: %w", group.ID, err)
+	}
+
+	var tokens []dto.Token
+	for _, serviceAccount := range serviceAccounts {
+		pats, err := gitlab.ScanAndCollect(func(p gitlab.PaginationOptionFunc) ([]*gitlab.PersonalAccessToken, *gitlab.Response, error) {
+			return a.gitlabClient.Groups.ListServiceAccountPersonalAccessTokens(group.ID, serviceAccount.ID, nil, p)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list personal access tokens of service account %s for group %d: %w",
+				serviceAccount.UserName, group.ID, err)
+		}
+		dtoTokens := ConvertPersonalGitlabTokenToDTOTokens(pats)
+		// Add the source: the group the service account belongs to, whose API manages the tokens
+		for i := range dtoTokens {
+			dtoTokens[i].Type = dto.TypeServiceAccountToken
+			dtoTokens[i].Source = group.Path
+			dtoTokens[i].SourceKind = dto.SourceKindGroup
+			dtoTokens[i].SourceID = group.ID
+			dtoTokens[i].Owner = serviceAccount.UserName
+			dtoTokens[i].OwnerID = serviceAccount.ID
+			dtoTokens[i].WebURL = group.WebURL + serviceAccountsSettingsPath
+		}
+		tokens = append(tokens, dtoTokens...)
+	}
+	return tokens, nil
+}
@@ -0,0 +1,59 @@
+package app_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/app"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestApp_GetTokensOfGroups_WithServiceAccounts(t *testing.T) {
+	mux := http.NewServeMux()
+	for _, id := range []string{"1", "2"} {
+		mux.HandleFunc("/api/v4/groups/"+id+"/access_tokens", func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, `[]`)
+		})
+		mux.HandleFunc("/api/v4/groups/"+id+"/deploy_tokens", func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, `[]`)
+		})
+	}
+	mux.HandleFunc("/api/v4/groups/1/service_accounts", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[{"id": 100, "name": "CI bot", "username": "service_account_group_1_abc"}]`)
+	})
+	mux.HandleFunc("/api/v4/groups/1/service_accounts/100/personal_access_tokens",
+		func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, `[{"id": 10, "name": "release", "user_id": 100, "scopes": ["api"], "expires_at": "2030-01-01"}]`)
+		})
+	// Service accounts belong to top-level groups only
+	mux.HandleFunc("/api/v4/groups/2/service_accounts", func(w http.ResponseWriter, _ *http.Request) {
+		t.Error("service accounts of a subgroup must not be listed")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL), app.WithServiceAccounts(true))
+	groups := []*gitlab.Group{
+		{ID: 2, ParentID: 1, Path: "child"},
+		{ID: 1, Path: "parent", WebURL: "https://gitlab.example.com/groups/parent"},
+	}
+	tokens, err := a.GetTokensOfGroups(context.Background(), groups)
+	require.NoError(t, err)
+
+	require.Len(t, tokens, 1)
+	assert.Equal(t, "release", tokens[0].Name)
+	assert.Equal(t, dto.TypeServiceAccountToken, tokens[0].Type)
+	assert.Equal(t, "parent", tokens[0].Source)
+	assert.Equal(t, dto.SourceKindGroup, tokens[0].SourceKind)
+	assert.Equal(t, int64(1), tokens[0].SourceID)
+	assert.Equal(t, "service_account_group_1_abc", tokens[0].Owner)
+	assert.Equal(t, int64(100), tokens[0].OwnerID)
+	assert.Equal(t, "2030-01-01", tokens[0].ExpiresAt)
+	assert.Equal(t, "https://gitlab.example.com/groups/parent/-/settings/service_accounts", tokens[0].WebURL)
+}
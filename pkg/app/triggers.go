@@ -0,0 +1,36 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// PipelineTrigger represents a pipeline trigger token.
+// The GitLab client does not decode the expiration date of trigger tokens, so it is added here.
+type PipelineTrigger struct {
+	gitlab.PipelineTrigger
+	ExpiresAt *gitlab.ISOTime `json:"expires_at"`
+}
+
+// listPipelineTriggers returns all the pipeline trigger tokens of a project.
+func (a *App) listPipelineTriggers(projectID int64) ([]*PipelineTrigger, error) {
+	triggers, err := gitlab.ScanAndCollect(func(p gitlab.PaginationOptionFunc) ([]*PipelineTrigger, *gitlab.Response, error) {
+		req, err := a.gitlabClient.NewRequest(http.MethodGet, fmt.Sprintf("projects/%d/triggers", projectID),
+			&gitlab.ListPipelineTriggersOptions{}, []gitlab.RequestOptionFunc{p})
+		if err != nil {
+			return nil, nil, err
+		}
+		var triggers []*PipelineTrigger
+		resp, err := a.gitlabClient.Do(req, &triggers)
+		if err != nil {
+			return nil, resp, err
+		}
+		return triggers, resp, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pipeline triggers for project %d: %w", projectID, err)
+	}
+	return triggers, nil
+}
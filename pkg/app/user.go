@@ -0,0 +1,193 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// Paths of the settings pages of the user-level credentials.
+const (
+	gpgKeysSettingsPath             = "/-/user_settings/gpg_keys"
+	impersonationTokensSettingsPath = "/impersonation_tokens"
+	applicationsSettingsPath        = "/admin/applications"
+)
+
+// WithOAuthApplications includes the OAuth applications of the instance in the user credentials.
+// GitLab does not expose through its API the applications a user has authorized, so the
+// applications registered on the instance are listed instead. It requires administrator access.
+func WithOAuthApplications(oauthApplications bool) Option {
+	return func(a *App) {
+		a.oauthApplications = oauthApplications
+	}
+}
+
+// GetUserCredentials returns the credentials of a user: personal access tokens, SSH keys, GPG keys
+// and, if the current user is an administrator, impersonation tokens.
+// If userID is 0, the credentials of the current user are returned.
+// Feed tokens and incoming email tokens are not returned: GitLab does not expose them through its API.
+func (a *App) GetUserCredentials(ctx context.Context, userID int64) ([]dto.Token, error) {
+	if err := a.checkClient(); err != nil {
+		return nil, err
+	}
+	currentUser, _, err := a.gitlabClient.Users.CurrentUser()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current user: %w", err)
+	}
+	user := currentUser
+	if userID != 0 && userID != currentUser.ID {
+		user, _, err = a.gitlabClient.Users.GetUser(userID, gitlab.GetUsersOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user %d: %w", userID, err)
+		}
+	}
+	self := user.ID == currentUser.ID
+
+	var res []dto.Token
+	pats, err := a.getPersonalAccessTokensOfUser(user)
+	if err != nil {
+		return nil, err
+	}
+	res = append(res, pats...)
+
+	sshKeys, err := a.getSSHKeysOfUser(user, self)
+	if err != nil {
+		return nil, err
+	}
+	res = append(res, sshKeys...)
+
+	gpgKeys, err := a.getGPGKeysOfUser(user, self)
+	if err != nil {
+		return nil, err
+	}
+	res = append(res, gpgKeys...)
+
+	if currentUser.IsAdmin {
+		impersonationTokens, err := a.getImpersonationTokensOfUser(user)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, impersonationTokens...)
+	}
+
+	if a.oauthApplications {
+		applications, err := a.GetOAuthApplications(ctx)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, applications...)
+	}
+	return res, nil
+}
+
+// getPersonalAccessTokensOfUser returns the personal access tokens of a user.
+func (a *App) getPersonalAccessTokensOfUser(user *gitlab.User) ([]dto.Token, error) {
+	pats, err := gitlab.ScanAndCollect(func(p gitlab.PaginationOptionFunc) ([]*gitlab.PersonalAccessToken, *gitlab.Response, error) {
+		return a.gitlabClient.PersonalAccessTokens.ListPersonalAccessTokens(
+			&gitlab.ListPersonalAccessTokensOptions{UserID: gitlab.Ptr(user.ID)}, p)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list personal access tokens of user %s: %w", user.Username, err)
+	}
+	tokens := ConvertPersonalGitlabTokenToDTOTokens(pats)
+	for i := range tokens {
+		tokens[i].WebURL = a.webURL() + personalAccessTokensSettingsPath
+	}
+	return withUser(tokens, user), nil
+}
+
+// getSSHKeysOfUser returns the SSH keys of a user.
+func (a *App) getSSHKeysOfUser(user *gitlab.User, self bool) ([]dto.Token, error) {
+	sshKeys, err := gitlab.ScanAndCollect(func(p gitlab.PaginationOptionFunc) ([]*gitlab.SSHKey, *gitlab.Response, error) {
+		if self {
+			return a.gitlabClient.Users.ListSSHKeys(nil, p)
+		}
+		return a.gitlabClient.Users.ListSSHKeysForUser(user.ID, nil, p)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SSH keys of user %s: %w", user.Username, err)
+	}
+	tokens := ConvertSSHKeyToDTOTokens(sshKeys)
+	for i := range tokens {
+		tokens[i].WebURL = a.webURL() + sshKeysSettingsPath
+	}
+	tokens = withUser(tokens, user)
+	if self {
+		// The SSH keys of the current user are deleted without user ID
+		for i := range tokens {
+			tokens[i].SourceID = 0
+		}
+	}
+	return tokens, nil
+}
+
+// getGPGKeysOfUser returns the GPG keys of a user.
+func (a *App) getGPGKeysOfUser(user *gitlab.User, self bool) ([]dto.Token, error) {
+	var gpgKeys []*gitlab.GPGKey
+	var err error
+	if self {
+		gpgKeys, _, err = a.gitlabClient.Users.ListGPGKeys()
+	} else {
+		gpgKeys, _, err = a.gitlabClient.Users.ListGPGKeysForUser(user.ID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list GPG keys of user %s: %w", user.Username, err)
+	}
+	tokens := ConvertGPGKeyToDTOTokens(gpgKeys)
+	for i := range tokens {
+		tokens[i].WebURL = a.webURL() + gpgKeysSettingsPath
+	}
+	tokens = withUser(tokens, user)
+	if self {
+		// The GPG keys of the current user are deleted without user ID
+		for i := range tokens {
+			tokens[i].SourceID = 0
+		}
+	}
+	return tokens, nil
+}
+
+// getImpersonationTokensOfUser returns the impersonation tokens of a user. It requires administrator access.
+func (a *App) getImpersonationTokensOfUser(user *gitlab.User) ([]dto.Token, error) {
+	impersonationTokens, err := gitlab.ScanAndCollect(func(p gitlab.PaginationOptionFunc) ([]*gitlab.ImpersonationToken, *gitlab.Response, error) {
+		return a.gitlabClient.Users.GetAllImpersonationTokens(user.ID, nil, p)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list impersonation tokens of user %s: %w", user.Username, err)
+	}
+	tokens := ConvertImpersonationTokenToDTOTokens(impersonationTokens)
+	for i := range tokens {
+		tokens[i].WebURL = fmt.Sprintf("%s/admin/users/%s%s", a.webURL(), user.Username, impersonationTokensSettingsPath)
+	}
+	return withUser(tokens, user), nil
+}
+
+// GetOAuthApplications returns the OAuth applications of the instance. It requires administrator access.
+func (a *App) GetOAuthApplications(_ context.Context) ([]dto.Token, error) {
+	if err := a.checkClient(); err != nil {
+		return nil, err
+	}
+	applications, err := gitlab.ScanAndCollect(func(p gitlab.PaginationOptionFunc) ([]*gitlab.Application, *gitlab.Response, error) {
+		return a.gitlabClient.Applications.ListApplications(nil, p)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list OAuth applications: %w", err)
+	}
+	tokens := ConvertOAuthApplicationToDTOTokens(applications)
+	for i := range tokens {
+		tokens[i].WebURL = a.webURL() + applicationsSettingsPath
+	}
+	return tokens, nil
+}
+
+// withUser sets the user as the source and owner of the tokens.
+func withUser(tokens []dto.Token, user *gitlab.User) []dto.Token {
+	for i := range tokens {
+		tokens[i].Source = user.Username
+		tokens[i].SourceID = user.ID
+		tokens[i].Owner = user.Username
+	}
+	return tokens
+}
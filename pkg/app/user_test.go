@@ -0,0 +1,90 @@
+package app_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/app"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newUserCredentialsServer(t *testing.T, isAdmin bool) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/user", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, `{"id": 1, "username": "alice", "is_admin": %t}`, isAdmin)
+	})
+	mux.HandleFunc("/api/v4/users/2", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"id": 2, "username": "bob"}`)
+	})
+	mux.HandleFunc("/api/v4/personal_access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"id": 10, "name": "pat-%s", "user_id": %s, "expires_at": "2030-01-01"}]`,
+			r.URL.Query().Get("user_id"), r.URL.Query().Get("user_id"))
+	})
+	mux.HandleFunc("/api/v4/user/keys", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[{"id": 20, "title": "laptop"}]`)
+	})
+	mux.HandleFunc("/api/v4/users/2/keys", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[{"id": 21, "title": "desktop"}]`)
+	})
+	mux.HandleFunc("/api/v4/user/gpg_keys", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[{"id": 30}]`)
+	})
+	mux.HandleFunc("/api/v4/users/2/gpg_keys", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[{"id": 31}]`)
+	})
+	mux.HandleFunc("/api/v4/users/2/impersonation_tokens", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[{"id": 40, "name": "support", "expires_at": "2030-06-01"}]`)
+	})
+	mux.HandleFunc("/api/v4/applications", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[{"id": 50, "application_name": "ci-app"}]`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestApp_GetUserCredentials_CurrentUser(t *testing.T) {
+	server := newUserCredentialsServer(t, false)
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL))
+
+	tokens, err := a.GetUserCredentials(context.Background(), 0)
+	require.NoError(t, err)
+
+	require.Len(t, tokens, 3)
+	assert.Equal(t, "pat-1", tokens[0].Name)
+	assert.Equal(t, dto.TypePersonalAccessToken, tokens[0].Type)
+	assert.Equal(t, int64(1), tokens[0].SourceID)
+	assert.Equal(t, dto.TypeSSHKey, tokens[1].Type)
+	assert.Equal(t, int64(0), tokens[1].SourceID, "keys of the current user are deleted without user ID")
+	assert.Equal(t, dto.TypeGPGKey, tokens[2].Type)
+	for _, token := range tokens {
+		assert.Equal(t, "alice", token.Source)
+		assert.Equal(t, "alice", token.Owner)
+	}
+}
+
+func TestApp_GetUserCredentials_OtherUserAsAdmin(t *testing.T) {
+	server := newUserCredentialsServer(t, true)
+	a := app.NewApp(&MockRenderer{}, app.WithGitlabEndpoint(server.URL), app.WithOAuthApplications(true))
+
+	tokens, err := a.GetUserCredentials(context.Background(), 2)
+	require.NoError(t, err)
+
+	require.Len(t, tokens, 5)
+	assert.Equal(t, "pat-2", tokens[0].Name)
+	assert.Equal(t, "desktop", tokens[1].Name)
+	assert.Equal(t, int64(2), tokens[1].SourceID)
+	assert.Equal(t, int64(31), tokens[2].ID)
+	assert.Equal(t, "support", tokens[3].Name)
+	assert.Equal(t, dto.TypeImpersonationToken, tokens[3].Type)
+	assert.Equal(t, "2030-06-01", tokens[3].ExpiresAt)
+	assert.Equal(t, "bob", tokens[3].Source)
+	assert.Equal(t, "ci-app", tokens[4].Name)
+	assert.Equal(t, dto.TypeOAuthApplication, tokens[4].Type)
+}
@@ -0,0 +1,116 @@
+// Package credentials finds the GitLab token used to call the API.
+//
+// The token is looked up in an ordered list of providers: the first one holding a token
+// for the GitLab host wins. The command line uses the following precedence:
+//
+//  1. the file given with --token-file
+//  2. the output of the command given with --token-command
+//  3. the GITLAB_TOKEN environment variable
+//  4. the entry of the host in ~/.netrc (or the file given by $NETRC)
+//  5. the token of the host in the configuration of the glab CLI
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ErrNoCredential is returned when no provider holds a token for the host.
+var ErrNoCredential = errors.New("no GitLab credential found")
+
+// Provider provides the token for a GitLab host.
+type Provider interface {
+	// Name describes where the token comes from.
+	Name() string
+	// Token returns the token for the host (e.g. gitlab.com), or an empty string
+	// if the provider does not hold any.
+	Token(ctx context.Context, host string) (string, error)
+}
+
+// Resolve returns the token for the host from the first provider holding one,
+// along with the name of that provider. It returns ErrNoCredential if no provider holds a token,
+// or the error of the first provider failing.
+func Resolve(ctx context.Context, host string, providers ...Provider) (string, string, error) {
+	names := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		token, err := provider.Token(ctx, host)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to get token from %s: %w", provider.Name(), err)
+		}
+		if token != "" {
+			return token, provider.Name(), nil
+		}
+		names = append(names, provider.Name())
+	}
+	return "", "", fmt.Errorf("%w for %s (looked up in %s)", ErrNoCredential, host, strings.Join(names, ", "))
+}
+
+// Env provides the token held by an environment variable.
+type Env string
+
+// Name implements Provider.
+func (e Env) Name() string {
+	return "environment variable " + string(e)
+}
+
+// Token implements Provider.
+func (e Env) Token(_ context.Context, _ string) (string, error) {
+	return strings.TrimSpace(os.Getenv(string(e))), nil
+}
+
+// File provides the token written in a file. The file must exist.
+type File string
+
+// Name implements Provider.
+func (f File) Name() string {
+	return "token file " + string(f)
+}
+
+// Token implements Provider.
+func (f File) Token(_ context.Context, _ string) (string, error) {
+	data, err := os.ReadFile(string(f))
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", string(f))
+	}
+	return token, nil
+}
+
+// Command provides the token printed by a command run with the shell
+// (e.g. "pass show gitlab/token"). The command must succeed and print a token.
+type Command string
+
+// Name implements Provider.
+func (c Command) Name() string {
+	return "token command"
+}
+
+// Token implements Provider.
+func (c Command) Token(ctx context.Context, _ string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", string(c))
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", string(c))
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("token command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	token := strings.TrimSpace(string(out))
+	if token == "" {
+		return "", errors.New("token command printed no token")
+	}
+	return token, nil
+}
@@ -0,0 +1,210 @@
+package credentials_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFile writes content in a file of a temporary directory and returns its path.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestResolve(t *testing.T) {
+	t.Setenv("TEST_GITLAB_TOKEN", "env-token")
+	t.Setenv("TEST_EMPTY_TOKEN", "")
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	tests := []struct {
+		name       string
+		providers  []credentials.Provider
+		wantToken  string
+		wantSource string
+		wantErr    error
+	}{
+		{
+			name: "first provider holding a token wins",
+			providers: []credentials.Provider{
+				credentials.Env("TEST_EMPTY_TOKEN"),
+				credentials.File(writeFile(t, "token", "file-token\n")),
+				credentials.Env("TEST_GITLAB_TOKEN"),
+			},
+			wantToken:  "file-token",
+			wantSource: "token file",
+		},
+		{
+			name: "missing netrc and glab files hold no token",
+			providers: []credentials.Provider{
+				credentials.Netrc(missing),
+				credentials.Glab(missing),
+				credentials.Env("TEST_GITLAB_TOKEN"),
+			},
+			wantToken:  "env-token",
+			wantSource: "environment variable TEST_GITLAB_TOKEN",
+		},
+		{
+			name:      "no token",
+			providers: []credentials.Provider{credentials.Env("TEST_EMPTY_TOKEN"), credentials.Netrc(missing)},
+			wantErr:   credentials.ErrNoCredential,
+		},
+		{
+			name:      "no provider",
+			providers: nil,
+			wantErr:   credentials.ErrNoCredential,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, source, err := credentials.Resolve(context.Background(), "gitlab.com", tt.providers...)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantToken, token)
+			assert.Contains(t, source, tt.wantSource)
+		})
+	}
+}
+
+func TestResolveStopsOnError(t *testing.T) {
+	t.Setenv("TEST_GITLAB_TOKEN", "env-token")
+	_, _, err := credentials.Resolve(context.Background(), "gitlab.com",
+		credentials.File(writeFile(t, "token", " \n")),
+		credentials.Env("TEST_GITLAB_TOKEN"),
+	)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, credentials.ErrNoCredential)
+}
+
+func TestFile(t *testing.T) {
+	_, err := credentials.File(filepath.Join(t.TempDir(), "missing")).Token(context.Background(), "gitlab.com")
+	require.Error(t, err)
+}
+
+func TestCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the commands of the test need a POSIX shell")
+	}
+
+	tests := []struct {
+		name    string
+		command string
+		want    string
+		wantErr bool
+	}{
+		{name: "token printed", command: "echo ' cmd-token '", want: "cmd-token"},
+		{name: "command failing", command: "echo oops >&2; exit 3", wantErr: true},
+		{name: "no token printed", command: "true", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := credentials.Command(tt.command).Token(context.Background(), "gitlab.com")
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, token)
+		})
+	}
+}
+
+func TestNetrc(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		host    string
+		want    string
+	}{
+		{
+			name:    "entry of the host",
+			content: "machine example.com login me password other\nmachine gitlab.com login me password secret\n",
+			host:    "gitlab.com",
+			want:    "secret",
+		},
+		{
+			name:    "entry on several lines",
+			content: "machine gitlab.com\n  login me\n  password secret\n",
+			host:    "gitlab.com",
+			want:    "secret",
+		},
+		{
+			name:    "default entry",
+			content: "machine example.com password other\ndefault login me password fallback\n",
+			host:    "gitlab.com",
+			want:    "fallback",
+		},
+		{
+			name:    "no entry",
+			content: "machine example.com password other\n",
+			host:    "gitlab.com",
+			want:    "",
+		},
+		{
+			name: "macro skipped",
+			content: "macdef init\nmachine gitlab.com password fake\n\n" +
+				"machine gitlab.com password secret\n",
+			host: "gitlab.com",
+			want: "secret",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := credentials.Netrc(writeFile(t, ".netrc", tt.content)).Token(context.Background(), tt.host)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, token)
+		})
+	}
+}
+
+func TestNetrcFromEnv(t *testing.T) {
+	t.Setenv("NETRC", writeFile(t, "netrc", "machine gitlab.example.com password secret\n"))
+	token, err := credentials.Netrc("").Token(context.Background(), "gitlab.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", token)
+}
+
+func TestGlab(t *testing.T) {
+	config := `git_protocol: ssh
+hosts:
+  gitlab.com:
+    token: glpat-secret
+    api_protocol: https
+  gitlab.example.com:
+    api_protocol: https
+`
+	path := writeFile(t, "config.yml", config)
+
+	token, err := credentials.Glab(path).Token(context.Background(), "gitlab.com")
+	require.NoError(t, err)
+	assert.Equal(t, "glpat-secret", token)
+
+	token, err = credentials.Glab(path).Token(context.Background(), "gitlab.example.com")
+	require.NoError(t, err)
+	assert.Empty(t, token)
+
+	_, err = credentials.Glab(writeFile(t, "config.yml", "hosts: [")).Token(context.Background(), "gitlab.com")
+	require.Error(t, err)
+}
+
+func TestGlabFromEnv(t *testing.T) {
+	dir := filepath.Dir(writeFile(t, "config.yml", "hosts:\n  gitlab.com:\n    token: glpat-secret\n"))
+	t.Setenv("GLAB_CONFIG_DIR", dir)
+	token, err := credentials.Glab("").Token(context.Background(), "gitlab.com")
+	require.NoError(t, err)
+	assert.Equal(t, "glpat-secret", token)
+}
@@ -0,0 +1,63 @@
+package credentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Glab provides the token of the host in the configuration file of the glab CLI.
+// If the path is empty, the file config.yml of $GLAB_CONFIG_DIR, or of the glab-cli directory
+// of $XDG_CONFIG_HOME or ~/.config, is used. A missing file holds no token.
+type Glab string
+
+// glabConfig is the part of the configuration of the glab CLI holding the tokens.
+type glabConfig struct {
+	Hosts map[string]struct {
+		Token string `yaml:"token"`
+	} `yaml:"hosts"`
+}
+
+// Name implements Provider.
+func (g Glab) Name() string {
+	return "glab configuration " + g.path()
+}
+
+// path returns the path of the configuration file of the glab CLI.
+func (g Glab) path() string {
+	if g != "" {
+		return string(g)
+	}
+	if dir := os.Getenv("GLAB_CONFIG_DIR"); dir != "" {
+		return filepath.Join(dir, "config.yml")
+	}
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "config.yml"
+		}
+		configDir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configDir, "glab-cli", "config.yml")
+}
+
+// Token implements Provider.
+func (g Glab) Token(_ context.Context, host string) (string, error) {
+	data, err := os.ReadFile(g.path())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read glab configuration: %w", err)
+	}
+	var config glabConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return "", fmt.Errorf("failed to parse glab configuration: %w", err)
+	}
+	return config.Hosts[host].Token, nil
+}
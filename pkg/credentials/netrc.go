@@ -0,0 +1,92 @@
+package credentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Netrc provides the password of the entry of the host in a netrc file.
+// If the path is empty, the file given by $NETRC or ~/.netrc is used. A missing file holds no token.
+type Netrc string
+
+// Name implements Provider.
+func (n Netrc) Name() string {
+	return "netrc file " + n.path()
+}
+
+// path returns the path of the netrc file.
+func (n Netrc) path() string {
+	if n != "" {
+		return string(n)
+	}
+	if path := os.Getenv("NETRC"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".netrc"
+	}
+	return filepath.Join(home, ".netrc")
+}
+
+// Token implements Provider.
+func (n Netrc) Token(_ context.Context, host string) (string, error) {
+	data, err := os.ReadFile(n.path())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read netrc file: %w", err)
+	}
+
+	var machine, defaultPassword string
+	inDefault := false
+	words := netrcWords(string(data))
+	for i := 0; i < len(words); i++ {
+		switch words[i] {
+		case "machine":
+			machine, inDefault = "", false
+			if i+1 < len(words) {
+				i++
+				machine = words[i]
+			}
+		case "default":
+			machine, inDefault = "", true
+		case "password":
+			if i+1 >= len(words) {
+				break
+			}
+			i++
+			switch {
+			case machine == host:
+				return words[i], nil
+			case inDefault:
+				defaultPassword = words[i]
+			}
+		}
+	}
+	return defaultPassword, nil
+}
+
+// netrcWords returns the words of a netrc file, without the macro definitions
+// which end with an empty line.
+func netrcWords(data string) []string {
+	var words []string
+	inMacro := false
+	for line := range strings.Lines(data) {
+		fields := strings.Fields(line)
+		switch {
+		case inMacro:
+			inMacro = len(fields) > 0
+		case len(fields) > 0 && fields[0] == "macdef":
+			inMacro = true
+		default:
+			words = append(words, fields...)
+		}
+	}
+	return words
+}
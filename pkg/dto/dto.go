@@ -10,4 +10,89 @@ type Token struct {
 	Name      string `json:"name"`
 	Revoked   bool   `json:"revoked"`
 	ExpiresAt string `json:"expires_at"`
+	// CreatedAt is the creation date of the token, if known.
+	CreatedAt string `json:"created_at,omitempty"`
+	// LastUsedAt is empty if the token has never been used
+	// or if GitLab does not track the usage of this type of token.
+	LastUsedAt string `json:"last_used_at,omitempty"`
+	// Owner is the username of the person responsible for the token, if known.
+	Owner string `json:"owner,omitempty"`
+	// Contacts are the people to notify about the token.
+	Contacts []Contact `json:"contacts,omitempty"`
+	Scopes   []string  `json:"scopes,omitempty"`
+	// SourceKind and SourceID identify the group, project or user the token belongs to.
+	SourceKind string `json:"source_kind,omitempty"`
+	SourceID   int64  `json:"source_id,omitempty"`
+	// WebURL is the URL of the settings page of the token.
+	WebURL string `json:"web_url,omitempty"`
+	// OwnerID is the ID of the service account owning a service account token.
+	OwnerID int64 `json:"owner_id,omitempty"`
+}
+
+// Kinds of source.
+const (
+	SourceKindGroup    = "group"
+	SourceKindProject  = "project"
+	SourceKindUser     = "user"
+	SourceKindInstance = "instance"
+)
+
+// Contact represents a GitLab user responsible for a token.
+type Contact struct {
+	Username string `json:"username"`
+	Name     string `json:"name,omitempty"`
+	Email    string `json:"email,omitempty"`
+}
+
+// Types of token.
+const (
+	TypeAccessToken         = "access_token"
+	TypeDeployToken         = "deploy_token"
+	TypePersonalAccessToken = "personal_access_token"
+	// TypeServiceAccountToken is a personal access token of a service account of a group.
+	TypeServiceAccountToken = "service_account_token"
+	TypePipelineTrigger     = "pipeline_trigger"
+	TypeDeployKey           = "deploy_key"
+	TypeSSHKey              = "ssh_key"
+	TypeImpersonationToken  = "impersonation_token"
+	TypeGPGKey              = "gpg_key"
+	TypeOAuthApplication    = "oauth_application"
+	TypeRunnerToken         = "runner_token"
+	TypeAgentToken          = "agent_token"
+	TypeWebhook             = "webhook"
+	TypeIntegration         = "integration"
+)
+
+// TracksUsage returns true if GitLab records the last usage of this type of token.
+func (t Token) TracksUsage() bool {
+	switch t.Type {
+	case TypeAccessToken, TypePersonalAccessToken, TypeServiceAccountToken, TypePipelineTrigger,
+		TypeImpersonationToken, TypeRunnerToken, TypeAgentToken:
+		return true
+	default:
+		return false
+	}
+}
+
+// CanRevoke returns true if GitLab can revoke this type of token. The other credentials can only be
+// deleted, irreversibly.
+func (t Token) CanRevoke() bool {
+	switch t.Type {
+	case TypeAccessToken, TypePersonalAccessToken, TypeServiceAccountToken, TypeImpersonationToken:
+		return true
+	default:
+		return false
+	}
+}
+
+// CanExpire returns false for the credentials that GitLab does not allow to expire
+// (webhook secrets, integration credentials, OAuth application secrets, agent tokens),
+// and for the GPG keys, whose expiration date GitLab does not expose.
+func (t Token) CanExpire() bool {
+	switch t.Type {
+	case TypeWebhook, TypeIntegration, TypeOAuthApplication, TypeAgentToken, TypeGPGKey:
+		return false
+	default:
+		return true
+	}
 }
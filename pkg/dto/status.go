@@ -0,0 +1,102 @@
+package dto
+
+import (
+	"math"
+	"time"
+)
+
+// DateFormat is the layout used for the dates of a Token.
+const DateFormat = "2006-01-02"
+
+// Status represents the expiration status of a token.
+type Status string
+
+// Possible statuses of a token.
+const (
+	StatusActive       Status = "active"
+	StatusExpiringSoon Status = "expiring_soon"
+	StatusExpired      Status = "expired"
+	StatusNeverExpires Status = "never_expires"
+	StatusRevoked      Status = "revoked"
+	// StatusNonExpiring is the status of the credentials that cannot expire.
+	StatusNonExpiring Status = "non_expiring"
+)
+
+// HasExpiration returns true if the token has an expiration date.
+func (t Token) HasExpiration() bool {
+	return t.ExpiresAt != ""
+}
+
+// Status returns the status of the token at the given time.
+// A token expiring within nbDaysBeforeExp days is considered as expiring soon.
+func (t Token) Status(now time.Time, nbDaysBeforeExp uint) Status {
+	if t.Revoked {
+		return StatusRevoked
+	}
+	if !t.CanExpire() {
+		return StatusNonExpiring
+	}
+	if !t.HasExpiration() {
+		return StatusNeverExpires
+	}
+	date, err := time.Parse(DateFormat, t.ExpiresAt)
+	if err != nil {
+		return StatusActive
+	}
+	if now.After(date) {
+		return StatusExpired
+	}
+	if nbDaysBeforeExp <= math.MaxInt32 && now.AddDate(0, 0, int(nbDaysBeforeExp)).After(date) {
+		return StatusExpiringSoon
+	}
+	return StatusActive
+}
+
+// Summary holds the number of tokens per status.
+type Summary struct {
+	Total        int `json:"total"`
+	Active       int `json:"active"`
+	ExpiringSoon int `json:"expiring_soon"`
+	Expired      int `json:"expired"`
+	NeverExpires int `json:"never_expires"`
+	Revoked      int `json:"revoked"`
+	NonExpiring  int `json:"non_expiring"`
+}
+
+// Summarize counts the tokens per status at the given time.
+func Summarize(tokens []Token, now time.Time, nbDaysBeforeExp uint) Summary {
+	s := Summary{Total: len(tokens)}
+	for _, token := range tokens {
+		switch token.Status(now, nbDaysBeforeExp) {
+		case StatusActive:
+			s.Active++
+		case StatusExpiringSoon:
+			s.ExpiringSoon++
+		case StatusExpired:
+			s.Expired++
+		case StatusNeverExpires:
+			s.NeverExpires++
+		case StatusRevoked:
+			s.Revoked++
+		case StatusNonExpiring:
+			s.NonExpiring++
+		}
+	}
+	return s
+}
+
+// IsStale returns true if the token tracks its usage and has never been used
+// or has not been used for more than nbDays days.
+func (t Token) IsStale(now time.Time, nbDays uint) bool {
+	if !t.TracksUsage() {
+		return false
+	}
+	if t.LastUsedAt == "" {
+		return true
+	}
+	lastUsed, err := time.Parse(DateFormat, t.LastUsedAt)
+	if err != nil || nbDays > math.MaxInt32 {
+		return false
+	}
+	return lastUsed.Before(now.AddDate(0, 0, -int(nbDays)))
+}
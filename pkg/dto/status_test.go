@@ -0,0 +1,80 @@
+package dto_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenStatus(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		token    dto.Token
+		expected dto.Status
+	}{
+		{"revoked", dto.Token{Revoked: true, ExpiresAt: "2030-01-01"}, dto.StatusRevoked},
+		{"never expires", dto.Token{}, dto.StatusNeverExpires},
+		{"expired", dto.Token{ExpiresAt: "2024-12-01"}, dto.StatusExpired},
+		{"expiring soon", dto.Token{ExpiresAt: "2025-01-15"}, dto.StatusExpiringSoon},
+		{"active", dto.Token{ExpiresAt: "2026-01-01"}, dto.StatusActive},
+		{"unparsable date", dto.Token{ExpiresAt: "invalid"}, dto.StatusActive},
+		{"non-expiring", dto.Token{Type: dto.TypeWebhook}, dto.StatusNonExpiring},
+		{"revoked non-expiring", dto.Token{Type: dto.TypeAgentToken, Revoked: true}, dto.StatusRevoked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.token.Status(now, 30))
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tokens := []dto.Token{
+		{ExpiresAt: "2026-01-01"},
+		{ExpiresAt: "2025-01-15"},
+		{ExpiresAt: "2024-12-01"},
+		{},
+		{},
+		{Type: dto.TypeIntegration},
+		{Revoked: true},
+	}
+
+	s := dto.Summarize(tokens, now, 30)
+
+	assert.Equal(t, dto.Summary{
+		Total:        7,
+		Active:       1,
+		ExpiringSoon: 1,
+		Expired:      1,
+		NeverExpires: 2,
+		Revoked:      1,
+		NonExpiring:  1,
+	}, s)
+}
+
+func TestTokenIsStale(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		token    dto.Token
+		expected bool
+	}{
+		{"recently used", dto.Token{Type: dto.TypeAccessToken, LastUsedAt: "2025-05-30"}, false},
+		{"unused for a long time", dto.Token{Type: dto.TypeAccessToken, LastUsedAt: "2025-01-01"}, true},
+		{"never used", dto.Token{Type: dto.TypePersonalAccessToken}, true},
+		{"usage not tracked", dto.Token{Type: dto.TypeDeployToken}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.token.IsStale(now, 90))
+		})
+	}
+}
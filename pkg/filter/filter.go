@@ -0,0 +1,47 @@
+// Package filter provides functions to select tokens.
+package filter
+
+import (
+	"time"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+)
+
+// Filter returns true if the token must be kept.
+type Filter func(token dto.Token) bool
+
+// Apply returns the tokens matching all the filters.
+func Apply(tokens []dto.Token, filters ...Filter) []dto.Token {
+	res := make([]dto.Token, 0, len(tokens))
+	for _, token := range tokens {
+		if Match(token, filters...) {
+			res = append(res, token)
+		}
+	}
+	return res
+}
+
+// Match returns true if the token matches all the filters.
+func Match(token dto.Token, filters ...Filter) bool {
+	for _, f := range filters {
+		if !f(token) {
+			return false
+		}
+	}
+	return true
+}
+
+// NeverExpires keeps only the tokens without expiration date.
+// The credentials that cannot expire are not kept.
+func NeverExpires() Filter {
+	return func(token dto.Token) bool {
+		return !token.Revoked && !token.HasExpiration() && token.CanExpire()
+	}
+}
+
+// Stale keeps only the active tokens never used or unused for more than nbDays days.
+func Stale(now time.Time, nbDays uint) Filter {
+	return func(token dto.Token) bool {
+		return !token.Revoked && token.IsStale(now, nbDays)
+	}
+}
@@ -0,0 +1,50 @@
+package filter_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/filter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApply(t *testing.T) {
+	tokens := []dto.Token{
+		{ID: 1, ExpiresAt: "2025-12-31"},
+		{ID: 2},
+		{ID: 3, Revoked: true},
+		{ID: 4, Type: dto.TypeGPGKey},
+	}
+
+	assert.Len(t, filter.Apply(tokens), 4)
+
+	res := filter.Apply(tokens, filter.NeverExpires())
+	assert.Len(t, res, 1)
+	assert.Equal(t, int64(2), res[0].ID)
+}
+
+func TestMatch(t *testing.T) {
+	keepAll := func(dto.Token) bool { return true }
+	keepNone := func(dto.Token) bool { return false }
+
+	assert.True(t, filter.Match(dto.Token{}))
+	assert.True(t, filter.Match(dto.Token{}, keepAll))
+	assert.False(t, filter.Match(dto.Token{}, keepAll, keepNone))
+}
+
+func TestStale(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	tokens := []dto.Token{
+		{ID: 1, Type: dto.TypeAccessToken, LastUsedAt: "2025-05-30"},
+		{ID: 2, Type: dto.TypeAccessToken, LastUsedAt: "2025-01-01"},
+		{ID: 3, Type: dto.TypePersonalAccessToken},
+		{ID: 4, Type: dto.TypeDeployToken},
+		{ID: 5, Type: dto.TypeAccessToken, Revoked: true},
+	}
+
+	res := filter.Apply(tokens, filter.Stale(now, 90))
+	assert.Len(t, res, 2)
+	assert.Equal(t, int64(2), res[0].ID)
+	assert.Equal(t, int64(3), res[1].ID)
+}
@@ -0,0 +1,34 @@
+package inventory_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/inventory"
+)
+
+func Example() {
+	scanner, err := inventory.NewScanner(
+		inventory.WithBaseURL("https://gitlab.example.com"),
+		inventory.WithToken(os.Getenv("GITLAB_TOKEN")),
+		inventory.WithArchived(inventory.ArchivedExclude),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for token, err := range scanner.Scan(context.Background(), inventory.Group{ID: 42}, inventory.User{}) {
+		var sourceErr *inventory.SourceError
+		if errors.As(err, &sourceErr) {
+			log.Printf("tokens of %s %d skipped: %v", sourceErr.Kind, sourceErr.ID, sourceErr.Err)
+			continue
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(token.Source, token.Name, token.ExpiresAt)
+	}
+}
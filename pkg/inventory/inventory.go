@@ -0,0 +1,154 @@
+// Package inventory scans GitLab for its tokens and other expirable credentials.
+//
+// It is the library API of gitlab-token-expiration, for Go programs embedding the scanner:
+//
+//	scanner, err := inventory.NewScanner(inventory.WithBaseURL("https://gitlab.example.com"),
+//		inventory.WithToken(os.Getenv("GITLAB_TOKEN")))
+//	if err != nil {
+//		return err
+//	}
+//	for token, err := range scanner.Scan(ctx, inventory.Group{ID: 42}) {
+//		var sourceErr *inventory.SourceError
+//		if errors.As(err, &sourceErr) {
+//			log.Printf("skipped: %v", sourceErr)
+//			continue
+//		}
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(token.Name, token.ExpiresAt)
+//	}
+//
+// The package follows the semantic versioning of the module: its exported identifiers, and the
+// fields of dto.Token, are not removed or changed incompatibly within a major version.
+// New options, targets and fields may be added.
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/app"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+)
+
+// SourceError is the error yielded by Scan when the tokens of a group, project, user or
+// of the instance could not be retrieved, e.g. because the token lacks the required role.
+// The scan goes on with the other sources.
+type SourceError struct {
+	// Kind is the kind of the source: dto.SourceKindGroup, dto.SourceKindProject,
+	// dto.SourceKindUser or dto.SourceKindInstance.
+	Kind string
+	// ID is the ID of the source, 0 for the instance or if unknown.
+	ID int64
+	// Name is the path of the source, if known.
+	Name string
+	// Err is the reason.
+	Err error
+}
+
+func (e *SourceError) Error() string {
+	switch {
+	case e.Name != "":
+		return fmt.Sprintf("%s %s (id %d): %v", e.Kind, e.Name, e.ID, e.Err)
+	case e.ID != 0:
+		return fmt.Sprintf("%s %d: %v", e.Kind, e.ID, e.Err)
+	default:
+		return fmt.Sprintf("%s: %v", e.Kind, e.Err)
+	}
+}
+
+// Unwrap returns the reason.
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// Scanner scans GitLab targets for their tokens. It is safe to run several scans one after the other,
+// but not concurrently.
+type Scanner struct {
+	app          *app.App
+	printRevoked bool
+	runners      bool
+	oauthApps    bool
+}
+
+// NewScanner returns a Scanner configured with the options. Without option, it scans gitlab.com
+// without token. It returns an error if an option is invalid.
+func NewScanner(opts ...Option) (*Scanner, error) {
+	s := &settings{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	a, err := app.New(nil, s.config, s.appOptions()...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidOption, err)
+	}
+	return &Scanner{app: a, printRevoked: s.revoked, runners: s.runners, oauthApps: s.oauthApplications}, nil
+}
+
+// BaseURL returns the URL of the GitLab API scanned.
+func (s *Scanner) BaseURL() string {
+	return s.app.BaseURL()
+}
+
+// Scan returns an iterator over the tokens of the targets, scanned in order. The tokens are yielded
+// as soon as the tokens of each group, project or user are retrieved.
+//
+// The errors yielded along with an empty token are *SourceError, after which the scan goes on,
+// except the error of the context, which ends the scan.
+func (s *Scanner) Scan(ctx context.Context, targets ...Target) iter.Seq2[dto.Token, error] {
+	return func(yield func(dto.Token, error) bool) {
+		y := &yielder{ctx: ctx, yield: yield, printRevoked: s.printRevoked}
+		for _, target := range targets {
+			if !target.scan(ctx, s, y) {
+				return
+			}
+		}
+	}
+}
+
+// yielder yields the results of a scan, and records whether the scan must stop.
+type yielder struct {
+	ctx          context.Context //nolint:containedctx // the context of the scan
+	yield        func(dto.Token, error) bool
+	printRevoked bool
+}
+
+// results yields the tokens, then the sources skipped in err. kind, id and name describe the source
+// of the tokens, reported if err is not an *app.ScanError. It returns false if the scan must stop.
+func (y *yielder) results(tokens []dto.Token, err error, kind string, id int64, name string) bool {
+	if ctxErr := y.ctx.Err(); ctxErr != nil {
+		y.yield(dto.Token{}, ctxErr)
+		return false
+	}
+	for _, token := range tokens {
+		if token.Revoked && !y.printRevoked {
+			continue
+		}
+		if !y.yield(token, nil) {
+			return false
+		}
+	}
+	if err == nil {
+		return true
+	}
+	var scanErr *app.ScanError
+	if !errors.As(err, &scanErr) {
+		return y.yield(dto.Token{}, &SourceError{Kind: kind, ID: id, Name: name, Err: err})
+	}
+	for _, skipped := range scanErr.Skipped {
+		sourceErr := &SourceError{Kind: skipped.SourceKind, ID: skipped.SourceID, Name: skipped.Source, Err: skipped.Err}
+		if sourceErr.Kind == "" {
+			sourceErr.Kind, sourceErr.ID, sourceErr.Name = kind, id, name
+		}
+		if !y.yield(dto.Token{}, sourceErr) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,187 @@
+package inventory_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/inventory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// responses are the responses of the test server by path. The other paths return an empty list.
+var responses = map[string]string{
+	"/api/v4/groups/1":                   `{"id": 1, "path": "top", "full_path": "top"}`,
+	"/api/v4/groups/1/descendant_groups": `[{"id": 2, "path": "sub", "full_path": "top/sub", "parent_id": 1}]`,
+	"/api/v4/groups/1/projects":          `[{"id": 10, "path": "app", "path_with_namespace": "top/app"}]`,
+	"/api/v4/groups/1/access_tokens": `[{"id": 100, "name": "group-token", "expires_at": "2030-01-01"},
+		{"id": 101, "name": "revoked-token", "revoked": true, "expires_at": "2030-01-01"}]`,
+	"/api/v4/projects/10":               `{"id": 10, "path": "app", "path_with_namespace": "top/app"}`,
+	"/api/v4/projects/10/access_tokens": `[{"id": 200, "name": "project-token", "expires_at": "2030-01-01"}]`,
+	"/api/v4/personal_access_tokens":    `[{"id": 300, "name": "pat", "user_id": 1, "expires_at": "2030-01-01"}]`,
+}
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/v4/groups/2/access_tokens" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message": "403 Forbidden"}`))
+			return
+		}
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			body = "[]"
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// collect returns the names of the tokens and the errors of the scan.
+func collect(ctx context.Context, t *testing.T, scanner *inventory.Scanner,
+	targets ...inventory.Target,
+) ([]string, []error) {
+	t.Helper()
+	var names []string
+	var errs []error
+	for token, err := range scanner.Scan(ctx, targets...) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		names = append(names, token.Name)
+	}
+	return names, errs
+}
+
+func TestScanner_Scan(t *testing.T) {
+	server := newServer(t)
+
+	tests := []struct {
+		name       string
+		opts       []inventory.Option
+		targets    []inventory.Target
+		wantTokens []string
+		wantErrs   int
+	}{
+		{
+			name:       "group with subgroups and projects",
+			targets:    []inventory.Target{inventory.Group{ID: 1}},
+			wantTokens: []string{"group-token", "project-token"},
+			wantErrs:   1,
+		},
+		{
+			name:       "group only",
+			targets:    []inventory.Target{inventory.Group{ID: 1, Shallow: true}},
+			wantTokens: []string{"group-token"},
+		},
+		{
+			name:       "revoked tokens",
+			opts:       []inventory.Option{inventory.WithRevoked(true)},
+			targets:    []inventory.Target{inventory.Group{ID: 1, Shallow: true}},
+			wantTokens: []string{"group-token", "revoked-token"},
+		},
+		{
+			name:       "excluded subgroup",
+			opts:       []inventory.Option{inventory.WithExcludedPaths("top/sub")},
+			targets:    []inventory.Target{inventory.Group{ID: 1}},
+			wantTokens: []string{"group-token", "project-token"},
+		},
+		{
+			name:       "several targets",
+			targets:    []inventory.Target{inventory.Project{ID: 10}, inventory.Instance{}},
+			wantTokens: []string{"project-token", "pat"},
+		},
+		{
+			name:     "missing group",
+			targets:  []inventory.Target{inventory.Group{ID: 404}},
+			wantErrs: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner, err := inventory.NewScanner(append(tt.opts, inventory.WithBaseURL(server.URL))...)
+			require.NoError(t, err)
+
+			names, errs := collect(context.Background(), t, scanner, tt.targets...)
+
+			assert.Equal(t, tt.wantTokens, names)
+			assert.Len(t, errs, tt.wantErrs)
+			for _, err := range errs {
+				var sourceErr *inventory.SourceError
+				require.ErrorAs(t, err, &sourceErr)
+			}
+		})
+	}
+}
+
+func TestScanner_Scan_SourceError(t *testing.T) {
+	server := newServer(t)
+	scanner, err := inventory.NewScanner(inventory.WithBaseURL(server.URL))
+	require.NoError(t, err)
+
+	_, errs := collect(context.Background(), t, scanner, inventory.Group{ID: 1})
+
+	require.Len(t, errs, 1)
+	var sourceErr *inventory.SourceError
+	require.ErrorAs(t, errs[0], &sourceErr)
+	assert.Equal(t, dto.SourceKindGroup, sourceErr.Kind)
+	assert.Equal(t, int64(2), sourceErr.ID)
+	assert.Contains(t, sourceErr.Error(), "403")
+}
+
+func TestScanner_Scan_Stop(t *testing.T) {
+	server := newServer(t)
+	scanner, err := inventory.NewScanner(inventory.WithBaseURL(server.URL))
+	require.NoError(t, err)
+
+	count := 0
+	for range scanner.Scan(context.Background(), inventory.Group{ID: 1}) {
+		count++
+		break
+	}
+	assert.Equal(t, 1, count)
+}
+
+func TestScanner_Scan_CanceledContext(t *testing.T) {
+	server := newServer(t)
+	scanner, err := inventory.NewScanner(inventory.WithBaseURL(server.URL))
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	names, errs := collect(ctx, t, scanner, inventory.Project{ID: 10}, inventory.Instance{})
+
+	assert.Empty(t, names)
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], context.Canceled)
+}
+
+func TestNewScanner_InvalidOption(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []inventory.Option
+	}{
+		{name: "URL without scheme", opts: []inventory.Option{inventory.WithBaseURL("gitlab.example.com")}},
+		{name: "archived", opts: []inventory.Option{inventory.WithArchived("all")}},
+		{
+			name: "GraphQL without forks",
+			opts: []inventory.Option{inventory.WithGraphQL(true), inventory.WithoutForks(true)},
+		},
+		{name: "excluded path pattern", opts: []inventory.Option{inventory.WithExcludedPaths("[")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := inventory.NewScanner(tt.opts...)
+			require.ErrorIs(t, err, inventory.ErrInvalidOption)
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/app"
+)
+
+// ErrInvalidOption is returned by NewScanner when an option is invalid.
+var ErrInvalidOption = errors.New("invalid option")
+
+// Archived is the handling of the archived projects in the scans of groups.
+type Archived string
+
+// Handling of the archived projects.
+const (
+	ArchivedInclude Archived = app.ArchivedInclude
+	ArchivedExclude Archived = app.ArchivedExclude
+	ArchivedOnly    Archived = app.ArchivedOnly
+)
+
+// Option is a function that configures the Scanner.
+type Option func(*settings)
+
+// settings are the settings of the Scanner.
+type settings struct {
+	config            app.Config
+	revoked           bool
+	owners            bool
+	runners           bool
+	agents            bool
+	serviceAccounts   bool
+	integrations      bool
+	oauthApplications bool
+	graphQL           bool
+	archived          Archived
+	sharedProjects    bool
+	skipForks         bool
+	excludedPaths     []string
+}
+
+// WithBaseURL sets the URL of the GitLab instance, https://gitlab.com by default.
+func WithBaseURL(baseURL string) Option {
+	return func(s *settings) {
+		s.config.BaseURL = baseURL
+	}
+}
+
+// WithToken sets the token used to call the GitLab API.
+func WithToken(token string) Option {
+	return func(s *settings) {
+		s.config.Token = token
+	}
+}
+
+// WithUserAgent sets the User-Agent header of the requests.
+func WithUserAgent(userAgent string) Option {
+	return func(s *settings) {
+		s.config.UserAgent = userAgent
+	}
+}
+
+// WithTimeout sets the timeout of a request, retries included. 0, the default, disables the timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(s *settings) {
+		s.config.Timeout = timeout
+	}
+}
+
+// WithMaxRPS limits the number of requests per second. 0, the default, disables the limit.
+func WithMaxRPS(maxRPS float64) Option {
+	return func(s *settings) {
+		s.config.MaxRPS = maxRPS
+	}
+}
+
+// WithCache saves the responses of the GitLab API in dir, and uses them during ttl before
+// revalidating them with their ETag.
+func WithCache(dir string, ttl time.Duration) Option {
+	return func(s *settings) {
+		s.config.CacheDir = dir
+		s.config.CacheTTL = ttl
+	}
+}
+
+// WithTransport sets the transport sending the requests, e.g. with the TLS or proxy settings of
+// transport.NewHTTPTransport. The requests are still retried, rate limited, logged and cached.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(s *settings) {
+		s.config.Transport = rt
+	}
+}
+
+// WithHTTPClient sends the requests with the HTTP client as is, without retries, rate limit, logs
+// and cache. It cannot be combined with WithTimeout, WithMaxRPS, WithCache and WithTransport.
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *settings) {
+		s.config.HTTPClient = client
+	}
+}
+
+// WithLogger logs the requests at debug level, and the sources skipped at info level.
+func WithLogger(log *slog.Logger) Option {
+	return func(s *settings) {
+		s.config.Logger = log
+	}
+}
+
+// WithRevoked includes the revoked tokens, skipped by default.
+func WithRevoked(revoked bool) Option {
+	return func(s *settings) {
+		s.revoked = revoked
+	}
+}
+
+// WithOwners resolves the owners and contacts of the tokens, with additional requests.
+func WithOwners(owners bool) Option {
+	return func(s *settings) {
+		s.owners = owners
+	}
+}
+
+// WithRunners includes the runners of the groups, projects and instance, with the expiration date of their token.
+func WithRunners(runners bool) Option {
+	return func(s *settings) {
+		s.runners = runners
+	}
+}
+
+// WithAgents includes the tokens of the agents for Kubernetes of the projects.
+func WithAgents(agents bool) Option {
+	return func(s *settings) {
+		s.agents = agents
+	}
+}
+
+// WithServiceAccounts includes the personal access tokens of the service accounts of the top-level groups.
+func WithServiceAccounts(serviceAccounts bool) Option {
+	return func(s *settings) {
+		s.serviceAccounts = serviceAccounts
+	}
+}
+
+// WithHooksAndIntegrations includes the hooks and integrations of the groups and projects,
+// whose credentials cannot expire.
+func WithHooksAndIntegrations(integrations bool) Option {
+	return func(s *settings) {
+		s.integrations = integrations
+	}
+}
+
+// WithOAuthApplications includes the OAuth applications of the instance in the scans of users
+// and of the instance. It requires administrator access.
+func WithOAuthApplications(oauthApplications bool) Option {
+	return func(s *settings) {
+		s.oauthApplications = oauthApplications
+	}
+}
+
+// WithGraphQL lists the subgroups and projects of the groups with the GraphQL API, with fewer requests
+// for large hierarchies. It cannot be combined with WithSharedProjects and WithoutForks.
+func WithGraphQL(graphQL bool) Option {
+	return func(s *settings) {
+		s.graphQL = graphQL
+	}
+}
+
+// WithArchived sets the handling of the archived projects, ArchivedInclude by default.
+func WithArchived(archived Archived) Option {
+	return func(s *settings) {
+		s.archived = archived
+	}
+}
+
+// WithSharedProjects includes the projects of other groups shared with the groups.
+func WithSharedProjects(sharedProjects bool) Option {
+	return func(s *settings) {
+		s.sharedProjects = sharedProjects
+	}
+}
+
+// WithoutForks skips the forks.
+func WithoutForks(skipForks bool) Option {
+	return func(s *settings) {
+		s.skipForks = skipForks
+	}
+}
+
+// WithExcludedPaths skips the subgroups and projects whose path, or the path of one of their
+// parents, matches one of the path.Match patterns.
+func WithExcludedPaths(patterns ...string) Option {
+	return func(s *settings) {
+		s.excludedPaths = append(s.excludedPaths, patterns...)
+	}
+}
+
+// validate returns an error if the settings are invalid.
+func (s *settings) validate() error {
+	switch s.archived {
+	case "", ArchivedInclude, ArchivedExclude, ArchivedOnly:
+	default:
+		return fmt.Errorf("%w: archived projects handling %q", ErrInvalidOption, s.archived)
+	}
+	if s.graphQL && (s.sharedProjects || s.skipForks) {
+		return fmt.Errorf("%w: the shared projects and forks cannot be filtered with GraphQL", ErrInvalidOption)
+	}
+	for _, pattern := range s.excludedPaths {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%w: excluded path pattern %q: %w", ErrInvalidOption, pattern, err)
+		}
+	}
+	return nil
+}
+
+// appOptions returns the options of the application scanning the targets.
+func (s *settings) appOptions() []app.Option {
+	api := app.RESTAPI
+	if s.graphQL {
+		api = app.GraphQLAPI
+	}
+	return []app.Option{
+		app.WithOwners(s.owners), app.WithRunners(s.runners), app.WithAgents(s.agents),
+		app.WithServiceAccounts(s.serviceAccounts), app.WithInventory(s.integrations),
+		app.WithOAuthApplications(s.oauthApplications), app.WithAPI(api), app.WithArchived(string(s.archived)),
+		app.WithSharedProjects(s.sharedProjects), app.WithoutForks(s.skipForks), app.WithExcludedPaths(s.excludedPaths),
+	}
+}
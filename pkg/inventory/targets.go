@@ -0,0 +1,100 @@
+package inventory
+
+import (
+	"context"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"gitlab.com/gitlab-org/api/client-go"
+)
+
+// Target is what Scan scans: a Group, a Project, a User or the Instance.
+type Target interface {
+	// scan yields the tokens of the target, and returns false if the scan must stop.
+	scan(ctx context.Context, s *Scanner, y *yielder) bool
+}
+
+// Group is a group, scanned with its subgroups and their projects unless Shallow is set.
+// Its access tokens and deploy tokens require the Owner role.
+type Group struct {
+	ID int64
+	// Shallow scans only the tokens of the group, without its subgroups and projects.
+	Shallow bool
+}
+
+// Project is a project. Its access tokens, deploy tokens and pipeline triggers require the Maintainer role.
+type Project struct {
+	ID int64
+}
+
+// User is a user, whose personal access tokens, SSH keys, GPG keys and, for administrators,
+// impersonation tokens are scanned. ID 0 is the current user; other users require administrator access.
+type User struct {
+	ID int64
+}
+
+// Instance is the whole instance: the personal access tokens and SSH keys of all users, the deploy
+// keys of the instance, and its runners and OAuth applications if enabled. It requires administrator access.
+type Instance struct{}
+
+func (g Group) scan(ctx context.Context, s *Scanner, y *yielder) bool {
+	group, err := s.app.GetGroup(g.ID)
+	if err != nil {
+		return y.results(nil, err, dto.SourceKindGroup, g.ID, "")
+	}
+	groups, projects := []*gitlab.Group{group}, []*gitlab.Project(nil)
+	if !g.Shallow {
+		groups, projects, err = s.app.GetGroupHierarchy(ctx, group)
+		// The subgroups and projects listed are scanned even if some could not be
+		if !y.results(nil, err, dto.SourceKindGroup, group.ID, group.FullPath) {
+			return false
+		}
+	}
+	for _, group := range groups {
+		tokens, err := s.app.GetTokensOfGroups(ctx, []*gitlab.Group{group})
+		if !y.results(tokens, err, dto.SourceKindGroup, group.ID, group.FullPath) {
+			return false
+		}
+	}
+	for _, project := range projects {
+		tokens, err := s.app.GetTokensOfProjects(ctx, []*gitlab.Project{project})
+		if !y.results(tokens, err, dto.SourceKindProject, project.ID, project.PathWithNamespace) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p Project) scan(ctx context.Context, s *Scanner, y *yielder) bool {
+	project, err := s.app.GetProject(p.ID)
+	if err != nil {
+		return y.results(nil, err, dto.SourceKindProject, p.ID, "")
+	}
+	tokens, err := s.app.GetTokensOfProjects(ctx, []*gitlab.Project{project})
+	return y.results(tokens, err, dto.SourceKindProject, project.ID, project.PathWithNamespace)
+}
+
+func (u User) scan(ctx context.Context, s *Scanner, y *yielder) bool {
+	tokens, err := s.app.GetUserCredentials(ctx, u.ID)
+	return y.results(tokens, err, dto.SourceKindUser, u.ID, "")
+}
+
+func (Instance) scan(ctx context.Context, s *Scanner, y *yielder) bool {
+	collectors := []func(context.Context) ([]dto.Token, error){
+		s.app.GetPersonalAccessTokens,
+		s.app.GetSSHKeysOfAllUsers,
+		s.app.GetInstanceDeployKeys,
+	}
+	if s.runners {
+		collectors = append(collectors, s.app.GetInstanceRunners)
+	}
+	if s.oauthApps {
+		collectors = append(collectors, s.app.GetOAuthApplications)
+	}
+	for _, collect := range collectors {
+		tokens, err := collect(ctx)
+		if !y.results(tokens, err, dto.SourceKindInstance, 0, "") {
+			return false
+		}
+	}
+	return true
+}
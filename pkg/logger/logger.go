@@ -2,10 +2,17 @@
 package logger
 
 import (
+	"io"
 	"log/slog"
 	"os"
 )
 
+// Formats of the logs.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
 // Logger is the interface for logging operations.
 type Logger interface {
 	Debug(msg string, args ...any)
@@ -14,11 +21,35 @@ type Logger interface {
 	Error(msg string, args ...any)
 }
 
+// config is the configuration of a logger.
+type config struct {
+	w      io.Writer
+	format string
+}
+
+// Option is a function that configures a logger created by NewLogger.
+type Option func(*config)
+
+// WithWriter sets the writer of the logs. The default is the standard error output,
+// which keeps the logs out of the output of the commands.
+func WithWriter(w io.Writer) Option {
+	return func(c *config) {
+		c.w = w
+	}
+}
+
+// WithFormat sets the format of the logs: FormatText (default) or FormatJSON.
+func WithFormat(format string) Option {
+	return func(c *config) {
+		c.format = format
+	}
+}
+
 // NewLogger creates a new logger
 // logLevel is the level of logging
 // Possible values of logLevel are: "debug", "info", "warn", "error"
 // Default value is "info".
-func NewLogger(logLevel string) *slog.Logger {
+func NewLogger(logLevel string, opts ...Option) *slog.Logger {
 	var level slog.Level
 	switch logLevel {
 	case "debug":
@@ -32,10 +63,20 @@ func NewLogger(logLevel string) *slog.Logger {
 	default:
 		level = slog.LevelInfo
 	}
-	logHandler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+	cfg := config{w: os.Stderr, format: FormatText}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	handlerOptions := &slog.HandlerOptions{
 		Level:     level,
 		AddSource: false,
-	})
+	}
+	var logHandler slog.Handler
+	if cfg.format == FormatJSON {
+		logHandler = slog.NewJSONHandler(cfg.w, handlerOptions)
+	} else {
+		logHandler = slog.NewTextHandler(cfg.w, handlerOptions)
+	}
 	logger := slog.New(logHandler)
 	return logger
 }
@@ -1,11 +1,14 @@
 package logger_test
 
 import (
+	"bytes"
+	"encoding/json"
 	"log/slog"
 	"testing"
 
 	"github.com/sgaunet/gitlab-token-expiration/pkg/logger"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNoLogger(t *testing.T) {
@@ -48,3 +51,16 @@ func TestNewLogger(t *testing.T) {
 		})
 	}
 }
+
+func TestNewLogger_Options(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.NewLogger("warn", logger.WithWriter(&buf), logger.WithFormat(logger.FormatJSON))
+	log.Info("This is an info message")
+	log.Warn("This is a warning message", "status", 503)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry), "a single JSON entry is logged")
+	assert.Equal(t, "WARN", entry["level"])
+	assert.Equal(t, "This is a warning message", entry["msg"])
+	assert.InDelta(t, 503, entry["status"], 0)
+}
@@ -0,0 +1,60 @@
+// Package policy checks tokens against security rules.
+package policy
+
+import (
+	"fmt"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+)
+
+// Rule names.
+const (
+	RuleNoExpiration = "no-expiration"
+)
+
+// Violation represents a token that does not comply with a rule.
+type Violation struct {
+	Rule    string    `json:"rule"`
+	Token   dto.Token `json:"token"`
+	Message string    `json:"message"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("[%s] %s %q (id %d, source %s): %s",
+		v.Rule, v.Token.Type, v.Token.Name, v.Token.ID, v.Token.Source, v.Message)
+}
+
+// Rule checks a token and returns a violation if the token does not comply.
+type Rule func(token dto.Token) (Violation, bool)
+
+// Check returns the violations of the tokens against the rules.
+// Revoked tokens are ignored.
+func Check(tokens []dto.Token, rules ...Rule) []Violation {
+	var violations []Violation
+	for _, token := range tokens {
+		if token.Revoked {
+			continue
+		}
+		for _, rule := range rules {
+			if v, ok := rule(token); ok {
+				violations = append(violations, v)
+			}
+		}
+	}
+	return violations
+}
+
+// NoExpiration reports active tokens without expiration date.
+// The credentials that cannot expire are not reported.
+func NoExpiration() Rule {
+	return func(token dto.Token) (Violation, bool) {
+		if token.HasExpiration() || !token.CanExpire() {
+			return Violation{}, false
+		}
+		return Violation{
+			Rule:    RuleNoExpiration,
+			Token:   token,
+			Message: "token never expires",
+		}, true
+	}
+}
@@ -0,0 +1,32 @@
+package policy_test
+
+import (
+	"testing"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckNoExpiration(t *testing.T) {
+	tokens := []dto.Token{
+		{ID: 1, Name: "with-expiry", ExpiresAt: "2025-12-31"},
+		{ID: 2, Name: "no-expiry", Type: "deploy_token", Source: "group"},
+		{ID: 3, Name: "revoked-no-expiry", Revoked: true},
+		{ID: 4, Name: "webhook", Type: dto.TypeWebhook},
+		// GitLab does not expose the expiration date of GPG keys
+		{ID: 5, Name: "GPG key 5", Type: dto.TypeGPGKey},
+	}
+
+	violations := policy.Check(tokens, policy.NoExpiration())
+
+	assert.Len(t, violations, 1)
+	assert.Equal(t, policy.RuleNoExpiration, violations[0].Rule)
+	assert.Equal(t, int64(2), violations[0].Token.ID)
+	assert.Contains(t, violations[0].String(), "no-expiry")
+}
+
+func TestCheckWithoutRules(t *testing.T) {
+	tokens := []dto.Token{{ID: 1, Name: "no-expiry"}}
+	assert.Empty(t, policy.Check(tokens))
+}
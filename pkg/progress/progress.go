@@ -0,0 +1,205 @@
+// Package progress reports the progress of a scan on the standard error output.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"golang.org/x/term"
+)
+
+// Modes of the progress reporting.
+const (
+	// ModeAuto redraws a status line when the output is a terminal, and reports nothing otherwise.
+	ModeAuto = "auto"
+	// ModePlain prints a status line periodically, for the logs of CI jobs.
+	ModePlain = "plain"
+	// ModeQuiet reports nothing.
+	ModeQuiet = "quiet"
+)
+
+// Default intervals between two status lines.
+const (
+	DefaultPlainInterval = 10 * time.Second
+	liveInterval         = 100 * time.Millisecond
+)
+
+// spinnerFrames are the frames of the spinner of the status line redrawn in a terminal.
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Reporter reports the progress of a scan: the groups and projects scanned, the tokens found
+// and the sources that could not be scanned. It is safe for concurrent use.
+type Reporter struct {
+	w        io.Writer
+	live     bool
+	interval time.Duration
+
+	mu            sync.Mutex
+	stage         string
+	groups        int
+	projects      int
+	groupsTotal   int
+	projectsTotal int
+	tokens        int
+	errors        int
+	frame         int
+	running       bool
+	stop          chan struct{}
+	done          chan struct{}
+}
+
+// Option is a function that configures the Reporter.
+type Option func(*Reporter)
+
+// WithInterval sets the interval between two status lines in plain mode.
+func WithInterval(interval time.Duration) Option {
+	return func(r *Reporter) {
+		r.interval = interval
+	}
+}
+
+// New returns a reporter writing on w in the given mode. It returns nil, which reports nothing,
+// in quiet mode, or in auto mode when w is not a terminal.
+func New(w io.Writer, mode string, opts ...Option) *Reporter {
+	r := &Reporter{w: w, interval: DefaultPlainInterval}
+	switch mode {
+	case ModePlain:
+	case ModeAuto:
+		if !isTerminal(w) {
+			return nil
+		}
+		r.live = true
+		r.interval = liveInterval
+	default:
+		return nil
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// isTerminal returns true if w is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd())) //nolint:gosec // file descriptors fit in an int
+}
+
+// Start starts a stage of the scan (e.g. "Listing subgroups and projects"), and the periodic
+// reporting if it is not running.
+func (r *Reporter) Start(stage string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stage = stage
+	if !r.live {
+		// A stage is reported as soon as it starts
+		r.printLocked()
+	}
+	if r.running {
+		return
+	}
+	r.running = true
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+	go r.run(r.stop, r.done)
+}
+
+// SetTotals sets the number of groups and projects to scan.
+func (r *Reporter) SetTotals(groups, projects int) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.groupsTotal = groups
+	r.projectsTotal = projects
+}
+
+// SourceScanned counts a group or project (dto.SourceKindGroup or dto.SourceKindProject) scanned,
+// the tokens found, and the error if it could not be scanned entirely.
+func (r *Reporter) SourceScanned(kind string, tokens int, err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch kind {
+	case dto.SourceKindGroup:
+		r.groups++
+	case dto.SourceKindProject:
+		r.projects++
+	}
+	r.tokens += tokens
+	if err != nil {
+		r.errors++
+	}
+}
+
+// Stop stops the periodic reporting and prints the final status line.
+func (r *Reporter) Stop() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	if !r.running {
+		r.mu.Unlock()
+		return
+	}
+	r.running = false
+	close(r.stop)
+	done := r.done
+	r.mu.Unlock()
+	<-done
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stage = "Done"
+	r.printLocked()
+	if r.live {
+		fmt.Fprintln(r.w)
+	}
+}
+
+// run prints the status line periodically until stop is closed.
+func (r *Reporter) run(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			r.mu.Lock()
+			r.printLocked()
+			r.mu.Unlock()
+		}
+	}
+}
+
+// printLocked prints the status line. In a terminal, it is redrawn in place with a spinner.
+func (r *Reporter) printLocked() {
+	status := fmt.Sprintf("%s: groups %s, projects %s, tokens %d, errors %d", r.stage,
+		count(r.groups, r.groupsTotal), count(r.projects, r.projectsTotal), r.tokens, r.errors)
+	if !r.live {
+		fmt.Fprintln(r.w, status)
+		return
+	}
+	r.frame = (r.frame + 1) % len(spinnerFrames)
+	fmt.Fprintf(r.w, "\r\033[K%s %s", spinnerFrames[r.frame], status)
+}
+
+// count returns the number of sources scanned, out of the total if known.
+func count(scanned, total int) string {
+	if total == 0 {
+		return fmt.Sprintf("%d", scanned)
+	}
+	return fmt.Sprintf("%d/%d", scanned, total)
+}
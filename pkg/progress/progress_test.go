@@ -0,0 +1,50 @@
+package progress_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/progress"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_Disabled(t *testing.T) {
+	var buf bytes.Buffer
+	// A buffer is not a terminal
+	assert.Nil(t, progress.New(&buf, progress.ModeAuto))
+	assert.Nil(t, progress.New(&buf, progress.ModeQuiet))
+
+	// A nil reporter reports nothing
+	var r *progress.Reporter
+	r.Start("Retrieving tokens")
+	r.SetTotals(1, 1)
+	r.SourceScanned(dto.SourceKindGroup, 1, nil)
+	r.Stop()
+	assert.Empty(t, buf.String())
+}
+
+func TestReporter_Plain(t *testing.T) {
+	var buf bytes.Buffer
+	r := progress.New(&buf, progress.ModePlain, progress.WithInterval(time.Hour))
+	require.NotNil(t, r)
+
+	r.Start("Listing subgroups and projects")
+	r.SetTotals(2, 3)
+	r.Start("Retrieving tokens")
+	r.SourceScanned(dto.SourceKindGroup, 2, nil)
+	r.SourceScanned(dto.SourceKindGroup, 0, errors.New("forbidden"))
+	r.SourceScanned(dto.SourceKindProject, 3, nil)
+	r.Stop()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, []string{
+		"Listing subgroups and projects: groups 0, projects 0, tokens 0, errors 0",
+		"Retrieving tokens: groups 0/2, projects 0/3, tokens 0, errors 0",
+		"Done: groups 2/2, projects 1/3, tokens 5, errors 1",
+	}, lines)
+}
@@ -0,0 +1,126 @@
+package snapshot
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+)
+
+// ErrInstanceMismatch is returned when comparing snapshots of different instances.
+var ErrInstanceMismatch = errors.New("snapshots were taken on different instances")
+
+// ChangeKind is the kind of change of a token between two snapshots.
+type ChangeKind string
+
+// Kinds of change.
+const (
+	ChangeCreated       ChangeKind = "created"
+	ChangeRevoked       ChangeKind = "revoked"
+	ChangeRemoved       ChangeKind = "removed"
+	ChangeRotated       ChangeKind = "rotated"
+	ChangeExpired       ChangeKind = "expired"
+	ChangeExpiryChanged ChangeKind = "expiry_changed"
+)
+
+// Change describes the change of a token between two snapshots.
+// Old is nil for created tokens, New is nil for removed tokens.
+type Change struct {
+	Kind ChangeKind `json:"kind"`
+	Old  *dto.Token `json:"old,omitempty"`
+	New  *dto.Token `json:"new,omitempty"`
+}
+
+// Token returns the most recent version of the token.
+func (c Change) Token() dto.Token {
+	if c.New != nil {
+		return *c.New
+	}
+	return *c.Old
+}
+
+// tokenKey identifies a token across snapshots.
+type tokenKey struct {
+	Source string
+	Type   string
+	ID     int64
+}
+
+// nameKey identifies the successive tokens created by a rotation.
+type nameKey struct {
+	Source string
+	Type   string
+	Name   string
+}
+
+func keyOf(t dto.Token) tokenKey {
+	return tokenKey{Source: t.Source, Type: t.Type, ID: t.ID}
+}
+
+func nameKeyOf(t dto.Token) nameKey {
+	return nameKey{Source: t.Source, Type: t.Type, Name: t.Name}
+}
+
+// Diff returns the changes of the tokens between the old and the new snapshot.
+//
+// A token created in the new snapshot with the same source, type and name as a token
+// revoked or removed since the old snapshot is reported as rotated.
+func Diff(oldSnap, newSnap Snapshot) ([]Change, error) {
+	if oldSnap.Instance != newSnap.Instance {
+		return nil, fmt.Errorf("%w: %s and %s", ErrInstanceMismatch, oldSnap.Instance, newSnap.Instance)
+	}
+	oldTokens := make(map[tokenKey]dto.Token, len(oldSnap.Tokens))
+	for _, t := range oldSnap.Tokens {
+		oldTokens[keyOf(t)] = t
+	}
+	newTokens := make(map[tokenKey]dto.Token, len(newSnap.Tokens))
+	for _, t := range newSnap.Tokens {
+		newTokens[keyOf(t)] = t
+	}
+
+	var changes []Change
+	// Tokens revoked or removed since the old snapshot, by name, candidates for a rotation
+	retired := make(map[nameKey][]int)
+	for _, o := range oldSnap.Tokens {
+		if o.Revoked {
+			continue
+		}
+		n, ok := newTokens[keyOf(o)]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Kind: ChangeRemoved, Old: &o})
+			retired[nameKeyOf(o)] = append(retired[nameKeyOf(o)], len(changes)-1)
+		case n.Revoked:
+			changes = append(changes, Change{Kind: ChangeRevoked, Old: &o, New: &n})
+			retired[nameKeyOf(o)] = append(retired[nameKeyOf(o)], len(changes)-1)
+		case o.ExpiresAt != n.ExpiresAt:
+			changes = append(changes, Change{Kind: ChangeExpiryChanged, Old: &o, New: &n})
+		case o.Status(oldSnap.TakenAt, 0) != dto.StatusExpired && n.Status(newSnap.TakenAt, 0) == dto.StatusExpired:
+			changes = append(changes, Change{Kind: ChangeExpired, Old: &o, New: &n})
+		}
+	}
+
+	for _, n := range newSnap.Tokens {
+		if _, ok := oldTokens[keyOf(n)]; ok {
+			continue
+		}
+		indexes := retired[nameKeyOf(n)]
+		if len(indexes) == 0 {
+			changes = append(changes, Change{Kind: ChangeCreated, New: &n})
+			continue
+		}
+		// The first retired token with the same name has been rotated
+		changes[indexes[0]].Kind = ChangeRotated
+		changes[indexes[0]].New = &n
+		retired[nameKeyOf(n)] = indexes[1:]
+	}
+
+	slices.SortStableFunc(changes, func(a, b Change) int {
+		ta, tb := a.Token(), b.Token()
+		return cmp.Or(strings.Compare(ta.Source, tb.Source), strings.Compare(ta.Name, tb.Name))
+	})
+	return changes, nil
+}
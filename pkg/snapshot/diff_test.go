@@ -0,0 +1,67 @@
+package snapshot_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/snapshot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	oldSnap := snapshot.Snapshot{
+		TakenAt:  time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+		Instance: "gitlab.com",
+		Tokens: []dto.Token{
+			{ID: 1, Source: "g/p", Type: dto.TypeAccessToken, Name: "unchanged", ExpiresAt: "2026-01-01"},
+			{ID: 2, Source: "g/p", Type: dto.TypeAccessToken, Name: "ci", ExpiresAt: "2025-06-30"},
+			{ID: 3, Source: "g/p", Type: dto.TypeDeployToken, Name: "revoked", ExpiresAt: "2026-01-01"},
+			{ID: 4, Source: "g/p", Type: dto.TypeDeployToken, Name: "removed", ExpiresAt: "2026-01-01"},
+			{ID: 5, Source: "g", Type: dto.TypeAccessToken, Name: "expiring", ExpiresAt: "2025-06-03"},
+			{ID: 6, Source: "g", Type: dto.TypeAccessToken, Name: "extended", ExpiresAt: "2025-07-01"},
+		},
+	}
+	newSnap := snapshot.Snapshot{
+		TakenAt:  time.Date(2025, 6, 8, 0, 0, 0, 0, time.UTC),
+		Instance: "gitlab.com",
+		Tokens: []dto.Token{
+			{ID: 1, Source: "g/p", Type: dto.TypeAccessToken, Name: "unchanged", ExpiresAt: "2026-01-01"},
+			{ID: 2, Source: "g/p", Type: dto.TypeAccessToken, Name: "ci", ExpiresAt: "2025-06-30", Revoked: true},
+			{ID: 7, Source: "g/p", Type: dto.TypeAccessToken, Name: "ci", ExpiresAt: "2025-09-30"},
+			{ID: 3, Source: "g/p", Type: dto.TypeDeployToken, Name: "revoked", ExpiresAt: "2026-01-01", Revoked: true},
+			{ID: 5, Source: "g", Type: dto.TypeAccessToken, Name: "expiring", ExpiresAt: "2025-06-03"},
+			{ID: 6, Source: "g", Type: dto.TypeAccessToken, Name: "extended", ExpiresAt: "2025-12-01"},
+			{ID: 8, Source: "g", Type: dto.TypeAccessToken, Name: "new"},
+		},
+	}
+
+	changes, err := snapshot.Diff(oldSnap, newSnap)
+	require.NoError(t, err)
+
+	kinds := make(map[string]snapshot.ChangeKind)
+	for _, c := range changes {
+		kinds[c.Token().Name] = c.Kind
+	}
+	assert.Equal(t, map[string]snapshot.ChangeKind{
+		"ci":       snapshot.ChangeRotated,
+		"revoked":  snapshot.ChangeRevoked,
+		"removed":  snapshot.ChangeRemoved,
+		"expiring": snapshot.ChangeExpired,
+		"extended": snapshot.ChangeExpiryChanged,
+		"new":      snapshot.ChangeCreated,
+	}, kinds)
+
+	for _, c := range changes {
+		if c.Kind == snapshot.ChangeRotated {
+			assert.Equal(t, int64(2), c.Old.ID)
+			assert.Equal(t, int64(7), c.New.ID)
+		}
+	}
+}
+
+func TestDiffInstanceMismatch(t *testing.T) {
+	_, err := snapshot.Diff(snapshot.Snapshot{Instance: "a"}, snapshot.Snapshot{Instance: "b"})
+	assert.ErrorIs(t, err, snapshot.ErrInstanceMismatch)
+}
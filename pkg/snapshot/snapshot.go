@@ -0,0 +1,170 @@
+// Package snapshot persists the tokens retrieved by a scan and compares scans over time.
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+)
+
+const (
+	fileExtension = ".json"
+	// nameLayout is the layout of the name of a snapshot, derived from the time it was taken.
+	nameLayout = "20060102T150405.000Z"
+	// maxCollisions is the maximum number of snapshots taken at the same millisecond.
+	maxCollisions = 100
+	dirPerm       = 0o700
+	filePerm      = 0o600
+)
+
+// ErrNotFound is returned when a snapshot does not exist.
+var ErrNotFound = errors.New("snapshot not found")
+
+// Snapshot holds the tokens retrieved by a scan of a GitLab instance.
+type Snapshot struct {
+	Name     string    `json:"-"`
+	TakenAt  time.Time `json:"taken_at"`
+	Instance string    `json:"instance"`
+	// Scope describes what was scanned, e.g. "group --id=42". Only the snapshots of the same scope
+	// can be compared. It is empty for the snapshots taken before it was recorded.
+	Scope  string      `json:"scope,omitempty"`
+	Tokens []dto.Token `json:"tokens"`
+}
+
+// New returns a snapshot of the tokens of the given instance and scope taken now.
+func New(instance, scope string, tokens []dto.Token) Snapshot {
+	return Snapshot{
+		TakenAt:  time.Now().UTC(),
+		Instance: instance,
+		Scope:    scope,
+		Tokens:   tokens,
+	}
+}
+
+// SameScope returns the snapshots of the given scope.
+func SameScope(snapshots []Snapshot, scope string) []Snapshot {
+	var res []Snapshot
+	for _, snap := range snapshots {
+		if snap.Scope == scope {
+			res = append(res, snap)
+		}
+	}
+	return res
+}
+
+// Store saves snapshots as JSON files in a directory.
+type Store struct {
+	dir string
+}
+
+// NewStore returns a store saving snapshots in dir.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// DefaultDir returns the default directory of the snapshots.
+func DefaultDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user config directory: %w", err)
+	}
+	return filepath.Join(configDir, "gitlab-token-expiration", "snapshots"), nil
+}
+
+// Save writes the snapshot in the store and returns its name.
+func (s *Store) Save(snap Snapshot) (string, error) {
+	if err := os.MkdirAll(s.dir, dirPerm); err != nil {
+		return "", fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	// A suffix is added to the name of the snapshots taken at the same time, instead of overwriting them
+	base := snap.TakenAt.UTC().Format(nameLayout)
+	for i := range maxCollisions {
+		name := base
+		if i > 0 {
+			name = fmt.Sprintf("%s-%d", base, i)
+		}
+		err := writeNewFile(filepath.Join(s.dir, name+fileExtension), data)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to write snapshot %s: %w", name, err)
+		}
+		return name, nil
+	}
+	return "", fmt.Errorf("failed to write snapshot %s: too many snapshots taken at the same time", base)
+}
+
+// writeNewFile writes data in a file that must not exist.
+func writeNewFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, filePerm)
+	if err != nil {
+		return err //nolint:wrapcheck // wrapped by Save
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err //nolint:wrapcheck // wrapped by Save
+	}
+	return f.Close() //nolint:wrapcheck // wrapped by Save
+}
+
+// Load reads the snapshot with the given name.
+func (s *Store) Load(name string) (Snapshot, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, strings.TrimSuffix(name, fileExtension)+fileExtension))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Snapshot{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+		}
+		return Snapshot{}, fmt.Errorf("failed to read snapshot %s: %w", name, err)
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return Snapshot{}, fmt.Errorf("failed to unmarshal snapshot %s: %w", name, err)
+	}
+	snap.Name = strings.TrimSuffix(name, fileExtension)
+	return snap, nil
+}
+
+// List returns the snapshots of the store sorted from the oldest to the most recent.
+// If instance is not empty, only the snapshots of this instance are returned.
+func (s *Store) List(instance string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read snapshot directory: %w", err)
+	}
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != fileExtension {
+			continue
+		}
+		snap, err := s.Load(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if instance != "" && snap.Instance != instance {
+			continue
+		}
+		snapshots = append(snapshots, snap)
+	}
+	slices.SortFunc(snapshots, func(a, b Snapshot) int {
+		if c := a.TakenAt.Compare(b.TakenAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return snapshots, nil
+}
@@ -0,0 +1,83 @@
+package snapshot_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/snapshot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	store := snapshot.NewStore(t.TempDir())
+
+	snapshots, err := store.List("")
+	require.NoError(t, err)
+	assert.Empty(t, snapshots)
+
+	first := snapshot.Snapshot{
+		TakenAt:  time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC),
+		Instance: "https://gitlab.com/api/v4/",
+		Tokens:   []dto.Token{{ID: 1, Name: "token", ExpiresAt: "2025-12-31"}},
+	}
+	second := snapshot.Snapshot{
+		TakenAt:  time.Date(2025, 6, 8, 8, 0, 0, 0, time.UTC),
+		Instance: "https://gitlab.com/api/v4/",
+	}
+	other := snapshot.Snapshot{
+		TakenAt:  time.Date(2025, 6, 2, 8, 0, 0, 0, time.UTC),
+		Instance: "https://gitlab.example.com/api/v4/",
+	}
+	for _, snap := range []snapshot.Snapshot{second, first, other} {
+		_, err := store.Save(snap)
+		require.NoError(t, err)
+	}
+
+	snapshots, err = store.List("https://gitlab.com/api/v4/")
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, "20250601T080000.000Z", snapshots[0].Name)
+	assert.Equal(t, "20250608T080000.000Z", snapshots[1].Name)
+
+	snapshots, err = store.List("")
+	require.NoError(t, err)
+	assert.Len(t, snapshots, 3)
+
+	loaded, err := store.Load("20250601T080000.000Z")
+	require.NoError(t, err)
+	assert.Equal(t, first.Tokens, loaded.Tokens)
+	assert.True(t, first.TakenAt.Equal(loaded.TakenAt))
+
+	_, err = store.Load("unknown")
+	assert.ErrorIs(t, err, snapshot.ErrNotFound)
+}
+
+func TestStore_SaveSameTime(t *testing.T) {
+	store := snapshot.NewStore(t.TempDir())
+	takenAt := time.Date(2025, 6, 1, 8, 0, 0, 123456789, time.UTC)
+
+	first, err := store.Save(snapshot.Snapshot{TakenAt: takenAt, Scope: "pat"})
+	require.NoError(t, err)
+	second, err := store.Save(snapshot.Snapshot{TakenAt: takenAt, Scope: "group --id=42"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "20250601T080000.123Z", first)
+	assert.Equal(t, "20250601T080000.123Z-1", second)
+	snapshots, err := store.List("")
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, "pat", snapshots[0].Scope)
+	assert.Equal(t, "group --id=42", snapshots[1].Scope)
+}
+
+func TestSameScope(t *testing.T) {
+	snapshots := []snapshot.Snapshot{{Name: "a", Scope: "pat"}, {Name: "b", Scope: "group --id=42"}, {Name: "c", Scope: "pat"}}
+
+	same := snapshot.SameScope(snapshots, "pat")
+
+	require.Len(t, same, 2)
+	assert.Equal(t, "a", same[0].Name)
+	assert.Equal(t, "c", same[1].Name)
+}
@@ -0,0 +1,182 @@
+package transport
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultCacheTTL is the default duration during which a cached response is used
+	// without asking the server.
+	DefaultCacheTTL = 10 * time.Minute
+	cacheDirPerm    = 0o700
+	cacheFilePerm   = 0o600
+)
+
+// authHeaders are the headers carrying the token of a request to the GitLab API.
+var authHeaders = []string{"Private-Token", "Job-Token", "Authorization"}
+
+// uncachedPaths are the API paths whose responses are never cached since they may hold
+// token secrets (e.g. the tokens of the pipeline triggers, or the tokens returned by a rotation).
+var uncachedPaths = []string{"/rotate", "/reset_authentication_token", "/reset_registration_token", "/triggers"}
+
+// cacheEntry is a response saved in the cache.
+type cacheEntry struct {
+	StoredAt time.Time   `json:"stored_at"`
+	Header   http.Header `json:"header"`
+	Body     []byte      `json:"body"`
+}
+
+// CacheTransport is an http.RoundTripper that saves the successful responses of GET requests
+// in a directory, so that repeated scans cost fewer requests.
+//
+// A cached response is used without asking the server during the TTL. Then it is revalidated
+// with its ETag: the server answers 304 Not Modified without body if the response did not change.
+// The responses are keyed by URL and by a fingerprint of the token, so that a token never
+// gets the responses of another one, and the token itself is not saved.
+type CacheTransport struct {
+	base http.RoundTripper
+	dir  string
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+// NewCacheTransport returns a CacheTransport saving the responses in dir during ttl,
+// and sending the requests with base, or with http.DefaultTransport if base is nil.
+func NewCacheTransport(base http.RoundTripper, dir string, ttl time.Duration) *CacheTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &CacheTransport{base: base, dir: dir, ttl: ttl, now: time.Now}
+}
+
+// DefaultCacheDir returns the default directory of the cache.
+func DefaultCacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user cache directory: %w", err)
+	}
+	return filepath.Join(cacheDir, "gitlab-token-expiration", "http"), nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *CacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !cacheable(req) {
+		return t.base.RoundTrip(req) //nolint:wrapcheck // the transport must return the errors unchanged
+	}
+	key := cacheKey(req)
+	entry, found := t.load(key)
+	if found && t.now().Sub(entry.StoredAt) < t.ttl {
+		return entry.response(req), nil
+	}
+
+	etag := ""
+	if found {
+		etag = entry.Header.Get("ETag")
+	}
+	if etag != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // the transport must return the errors unchanged
+	}
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		if etag == "" {
+			return resp, nil
+		}
+		_ = resp.Body.Close()
+		entry.StoredAt = t.now()
+		t.save(key, entry)
+		return entry.response(req), nil
+	case http.StatusOK:
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response of %s: %w", req.URL.Path, err)
+		}
+		header := resp.Header.Clone()
+		header.Del("Set-Cookie")
+		t.save(key, cacheEntry{StoredAt: t.now(), Header: header, Body: body})
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		return resp, nil
+	default:
+		return resp, nil
+	}
+}
+
+// load returns the cached response of the key, if any.
+func (t *CacheTransport) load(key string) (cacheEntry, bool) {
+	data, err := os.ReadFile(filepath.Join(t.dir, key+".json"))
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// save saves the response of the key. The cache is an optimization, so the errors
+// are ignored: the response is requested again on the next run.
+func (t *CacheTransport) save(key string, entry cacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(t.dir, cacheDirPerm); err != nil {
+		return
+	}
+	_ = os.WriteFile(filepath.Join(t.dir, key+".json"), data, cacheFilePerm)
+}
+
+// response returns the cached response to the request.
+func (e cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// cacheable returns true if the response to the request can be cached.
+func cacheable(req *http.Request) bool {
+	if req.Method != http.MethodGet {
+		return false
+	}
+	for _, path := range uncachedPaths {
+		if strings.HasSuffix(req.URL.Path, path) {
+			return false
+		}
+	}
+	return true
+}
+
+// cacheKey returns the key of the response to the request, derived from its URL and
+// from a fingerprint of its token.
+func cacheKey(req *http.Request) string {
+	h := sha256.New()
+	for _, name := range authHeaders {
+		fmt.Fprintf(h, "%s=%s\n", name, req.Header.Get(name))
+	}
+	h.Write([]byte(req.URL.String()))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,132 @@
+package transport_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// etagServer returns a server answering with an ETag, and 304 Not Modified when the ETag matches.
+func etagServer(t *testing.T, requests, notModified *atomic.Int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("X-Total-Pages", "1")
+		_, _ = io.WriteString(w, `[{"id": 1}]`)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func get(t *testing.T, client *http.Client, method, url, token string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(t.Context(), method, url, nil)
+	require.NoError(t, err)
+	req.Header.Set("Private-Token", token)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(body)
+}
+
+func TestCacheTransport_TTL(t *testing.T) {
+	var requests, notModified atomic.Int32
+	server := etagServer(t, &requests, &notModified)
+	client := &http.Client{Transport: transport.NewCacheTransport(nil, t.TempDir(), time.Hour)}
+
+	for range 3 {
+		status, body := get(t, client, http.MethodGet, server.URL+"/api/v4/groups", "glpat-a")
+		assert.Equal(t, http.StatusOK, status)
+		assert.JSONEq(t, `[{"id": 1}]`, body)
+	}
+	// The cached response is used without asking the server during the TTL
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestCacheTransport_ETag(t *testing.T) {
+	var requests, notModified atomic.Int32
+	server := etagServer(t, &requests, &notModified)
+	dir := t.TempDir()
+
+	for range 2 {
+		// A new transport for each run, as with a new process
+		client := &http.Client{Transport: transport.NewCacheTransport(nil, dir, 0)}
+		status, body := get(t, client, http.MethodGet, server.URL+"/api/v4/groups", "glpat-a")
+		assert.Equal(t, http.StatusOK, status)
+		assert.JSONEq(t, `[{"id": 1}]`, body)
+	}
+	// The expired response is revalidated with its ETag
+	assert.Equal(t, int32(2), requests.Load())
+	assert.Equal(t, int32(1), notModified.Load())
+}
+
+func TestCacheTransport_KeyedByToken(t *testing.T) {
+	var requests, notModified atomic.Int32
+	server := etagServer(t, &requests, &notModified)
+	dir := t.TempDir()
+	client := &http.Client{Transport: transport.NewCacheTransport(nil, dir, time.Hour)}
+
+	get(t, client, http.MethodGet, server.URL+"/api/v4/groups", "glpat-a")
+	get(t, client, http.MethodGet, server.URL+"/api/v4/groups", "glpat-b")
+	assert.Equal(t, int32(2), requests.Load())
+	assert.Equal(t, int32(0), notModified.Load())
+
+	// The tokens are not saved in the cache
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	for _, entry := range entries {
+		data, err := os.ReadFile(dir + "/" + entry.Name())
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "glpat-")
+	}
+}
+
+func TestCacheTransport_Uncached(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+	}{
+		{name: "rotate", method: http.MethodPost, path: "/api/v4/personal_access_tokens/1/rotate"},
+		{name: "revoke", method: http.MethodDelete, path: "/api/v4/personal_access_tokens/1"},
+		{name: "pipeline triggers", method: http.MethodGet, path: "/api/v4/projects/1/triggers"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				requests.Add(1)
+				_, _ = io.WriteString(w, `{"token": "glpat-secret"}`)
+			}))
+			defer server.Close()
+			dir := t.TempDir()
+			client := &http.Client{Transport: transport.NewCacheTransport(nil, dir, time.Hour)}
+
+			for range 2 {
+				_, body := get(t, client, tt.method, server.URL+tt.path, "glpat-a")
+				assert.Contains(t, body, "glpat-secret")
+			}
+			assert.Equal(t, int32(2), requests.Load())
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			assert.Empty(t, entries)
+		})
+	}
+}
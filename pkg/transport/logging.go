@@ -0,0 +1,124 @@
+package transport
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/logger"
+)
+
+// redacted replaces the secrets in the logs.
+const redacted = "REDACTED"
+
+// secretHeaders are the headers whose values are redacted in the logs.
+var secretHeaders = map[string]bool{
+	"Private-Token": true,
+	"Job-Token":     true,
+	"Authorization": true,
+	"Cookie":        true,
+	"Set-Cookie":    true,
+}
+
+// secretParams are the query parameters whose values are redacted in the logs.
+var secretParams = []string{"private_token", "access_token", "job_token", "token"}
+
+// LoggingTransport is an http.RoundTripper that logs every request sent: its method, URL,
+// page, response status and duration. The tokens in the URL and headers are redacted.
+// The requests are logged at debug level, and the failures of the server at warn level.
+type LoggingTransport struct {
+	mu   sync.RWMutex
+	base http.RoundTripper
+	log  logger.Logger
+}
+
+// NewLoggingTransport returns a LoggingTransport logging to log the requests sent with base,
+// or with http.DefaultTransport if base is nil.
+func NewLoggingTransport(base http.RoundTripper, log logger.Logger) *LoggingTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &LoggingTransport{base: base, log: log}
+}
+
+// SetLogger sets the logger.
+func (t *LoggingTransport) SetLogger(log logger.Logger) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.log = log
+}
+
+// SetBase sets the transport sending the requests, http.DefaultTransport if base is nil.
+func (t *LoggingTransport) SetBase(base http.RoundTripper) {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.base = base
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *LoggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.RLock()
+	base, log := t.base, t.log
+	t.mu.RUnlock()
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	args := []any{
+		"method", req.Method,
+		"url", RedactURL(req.URL),
+		"duration", time.Since(start).Round(time.Millisecond),
+	}
+	if page := req.URL.Query().Get("page"); page != "" {
+		args = append(args, "page", page)
+	}
+	if err != nil {
+		log.Warn("API request failed", append(args, "error", err)...)
+		return nil, err //nolint:wrapcheck // the transport must return the errors unchanged
+	}
+	args = append(args, "status", resp.StatusCode)
+	if resp.StatusCode >= http.StatusInternalServerError {
+		log.Warn("API request", args...)
+		return resp, nil
+	}
+	log.Debug("API request", append(args, "headers", RedactHeader(req.Header))...)
+	return resp, nil
+}
+
+// RedactURL returns the URL with the values of its user information and of its query
+// parameters holding tokens redacted.
+func RedactURL(u *url.URL) string {
+	redactedURL := *u
+	if redactedURL.User != nil {
+		redactedURL.User = url.User(redacted)
+	}
+	query := redactedURL.Query()
+	changed := false
+	for name := range query {
+		for _, secret := range secretParams {
+			if strings.EqualFold(name, secret) {
+				query.Set(name, redacted)
+				changed = true
+			}
+		}
+	}
+	if changed {
+		redactedURL.RawQuery = query.Encode()
+	}
+	return redactedURL.String()
+}
+
+// RedactHeader returns the header with the values of the headers holding tokens redacted.
+func RedactHeader(header http.Header) http.Header {
+	res := header.Clone()
+	for name := range res {
+		if secretHeaders[http.CanonicalHeaderKey(name)] {
+			res.Set(name, redacted)
+		}
+	}
+	return res
+}
@@ -0,0 +1,244 @@
+// Package transport provides HTTP round trippers used to call the GitLab API.
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Default retry settings.
+const (
+	DefaultMaxRetries = 5
+	DefaultMinWait    = 500 * time.Millisecond
+	DefaultMaxWait    = 30 * time.Second
+	// maxRetryAfter caps the wait requested by the server.
+	maxRetryAfter = 2 * time.Minute
+	// maxDrain is the maximum number of bytes read from a response discarded before a retry,
+	// so that the connection can be reused.
+	maxDrain = 4096
+)
+
+// RetryTransport is an http.RoundTripper that retries idempotent requests (GET, HEAD)
+// rejected by the rate limit (429) or failed with a temporary server error (502, 503, 504).
+//
+// It waits for the delay given by the Retry-After header, or until the reset time given by
+// the RateLimit-Reset header when the rate limit is reached, or with an exponential backoff
+// with jitter otherwise. It can also limit the number of requests per second.
+type RetryTransport struct {
+	base       http.RoundTripper
+	maxRetries int
+	minWait    time.Duration
+	maxWait    time.Duration
+	limiter    *rate.Limiter
+	sleep      func(ctx context.Context, d time.Duration) error
+	now        func() time.Time
+
+	mu           sync.Mutex
+	blockedUntil time.Time // reset time of the rate limit when no request remains
+}
+
+// RetryOption is a function that configures the RetryTransport.
+type RetryOption func(*RetryTransport)
+
+// WithMaxRetries sets the maximum number of retries of a request.
+func WithMaxRetries(maxRetries int) RetryOption {
+	return func(t *RetryTransport) {
+		t.maxRetries = maxRetries
+	}
+}
+
+// WithBackoff sets the minimum and maximum wait between two attempts.
+func WithBackoff(minWait, maxWait time.Duration) RetryOption {
+	return func(t *RetryTransport) {
+		t.minWait = minWait
+		t.maxWait = maxWait
+	}
+}
+
+// WithMaxRPS limits the number of requests per second. 0 disables the limit.
+func WithMaxRPS(maxRPS float64) RetryOption {
+	return func(t *RetryTransport) {
+		t.SetMaxRPS(maxRPS)
+	}
+}
+
+// WithSleep sets the function used to wait between two attempts.
+func WithSleep(sleep func(ctx context.Context, d time.Duration) error) RetryOption {
+	return func(t *RetryTransport) {
+		t.sleep = sleep
+	}
+}
+
+// NewRetryTransport returns a RetryTransport sending the requests with base,
+// or with http.DefaultTransport if base is nil.
+func NewRetryTransport(base http.RoundTripper, opts ...RetryOption) *RetryTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := &RetryTransport{
+		base:       base,
+		maxRetries: DefaultMaxRetries,
+		minWait:    DefaultMinWait,
+		maxWait:    DefaultMaxWait,
+		sleep:      sleep,
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// SetMaxRPS limits the number of requests per second. 0 disables the limit.
+func (t *RetryTransport) SetMaxRPS(maxRPS float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if maxRPS <= 0 {
+		t.limiter = nil
+		return
+	}
+	t.limiter = rate.NewLimiter(rate.Limit(maxRPS), 1)
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead
+	for attempt := 0; ; attempt++ {
+		if err := t.wait(req.Context()); err != nil {
+			return nil, err
+		}
+		resp, err := t.base.RoundTrip(req)
+		if resp != nil {
+			t.observe(resp)
+		}
+		if !idempotent || attempt >= t.maxRetries || !retryable(req.Context(), resp, err) {
+			return resp, err //nolint:wrapcheck // the transport must return the errors unchanged
+		}
+		delay := t.delay(resp, attempt)
+		if resp != nil {
+			_, _ = io.CopyN(io.Discard, resp.Body, maxDrain)
+			_ = resp.Body.Close()
+		}
+		if err := t.sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// wait waits for the rate limit to be reset and for the request rate limiter.
+func (t *RetryTransport) wait(ctx context.Context) error {
+	t.mu.Lock()
+	blockedFor := t.blockedUntil.Sub(t.now())
+	limiter := t.limiter
+	t.mu.Unlock()
+
+	if blockedFor > 0 {
+		if err := t.sleep(ctx, min(blockedFor, maxRetryAfter)); err != nil {
+			return err
+		}
+	}
+	if limiter != nil {
+		if err := limiter.Wait(ctx); err != nil {
+			return fmt.Errorf("rate limiter: %w", err)
+		}
+	}
+	return nil
+}
+
+// observe records the reset time of the rate limit when no request remains.
+func (t *RetryTransport) observe(resp *http.Response) {
+	if resp.Header.Get("RateLimit-Remaining") != "0" {
+		return
+	}
+	reset, ok := parseReset(resp.Header.Get("RateLimit-Reset"))
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if reset.After(t.blockedUntil) {
+		t.blockedUntil = reset
+	}
+}
+
+// delay returns the wait before the next attempt.
+func (t *RetryTransport) delay(resp *http.Response, attempt int) time.Duration {
+	if resp != nil {
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), t.now()); ok {
+			return min(d, maxRetryAfter)
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			if reset, ok := parseReset(resp.Header.Get("RateLimit-Reset")); ok && reset.After(t.now()) {
+				return min(reset.Sub(t.now()), maxRetryAfter)
+			}
+		}
+	}
+	// Exponential backoff with jitter: a random wait between half and all of the backoff
+	backoff := t.maxWait
+	if attempt < 32 && t.minWait<<attempt < t.maxWait {
+		backoff = t.minWait << attempt
+	}
+	half := backoff / 2
+	return half + rand.N(half+1) //nolint:gosec // jitter does not need a secure random generator
+}
+
+// retryable returns true if the request failed temporarily.
+func retryable(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		return ctx.Err() == nil && !errors.Is(err, context.Canceled)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseRetryAfter parses the Retry-After header, given in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// parseReset parses the RateLimit-Reset header, given as a Unix timestamp.
+func parseReset(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	timestamp, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(timestamp, 0), true
+}
+
+// sleep waits for the duration or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("waiting before retry: %w", ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,185 @@
+package transport_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordSleeps returns a sleep function recording the waits without waiting.
+func recordSleeps(waits *[]time.Duration) transport.RetryOption {
+	return transport.WithSleep(func(_ context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		return nil
+	})
+}
+
+func TestRetryTransport_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		failures     int
+		status       int
+		header       http.Header
+		wantStatus   int
+		wantAttempts int32
+		wantWaits    []time.Duration
+	}{
+		{
+			name:         "success",
+			method:       http.MethodGet,
+			wantStatus:   http.StatusOK,
+			wantAttempts: 1,
+		},
+		{
+			name:         "retry after in seconds",
+			method:       http.MethodGet,
+			failures:     2,
+			status:       http.StatusTooManyRequests,
+			header:       http.Header{"Retry-After": {"3"}},
+			wantStatus:   http.StatusOK,
+			wantAttempts: 3,
+			wantWaits:    []time.Duration{3 * time.Second, 3 * time.Second},
+		},
+		{
+			name:         "server error is retried",
+			method:       http.MethodGet,
+			failures:     1,
+			status:       http.StatusServiceUnavailable,
+			wantStatus:   http.StatusOK,
+			wantAttempts: 2,
+		},
+		{
+			name:         "too many failures",
+			method:       http.MethodGet,
+			failures:     10,
+			status:       http.StatusBadGateway,
+			wantStatus:   http.StatusBadGateway,
+			wantAttempts: 3,
+		},
+		{
+			name:         "client error is not retried",
+			method:       http.MethodGet,
+			failures:     1,
+			status:       http.StatusNotFound,
+			wantStatus:   http.StatusNotFound,
+			wantAttempts: 1,
+		},
+		{
+			name:         "post is not retried",
+			method:       http.MethodPost,
+			failures:     1,
+			status:       http.StatusTooManyRequests,
+			header:       http.Header{"Retry-After": {"3"}},
+			wantStatus:   http.StatusTooManyRequests,
+			wantAttempts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if int(attempts.Add(1)) <= tt.failures {
+					for k, v := range tt.header {
+						w.Header()[k] = v
+					}
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			var waits []time.Duration
+			client := &http.Client{Transport: transport.NewRetryTransport(nil,
+				transport.WithMaxRetries(2), recordSleeps(&waits))}
+			req, err := http.NewRequestWithContext(t.Context(), tt.method, server.URL, nil)
+			require.NoError(t, err)
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Equal(t, tt.wantAttempts, attempts.Load())
+			if tt.wantWaits != nil {
+				assert.Equal(t, tt.wantWaits, waits)
+			}
+			assert.Len(t, waits, int(tt.wantAttempts)-1)
+		})
+	}
+}
+
+func TestRetryTransport_Backoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	client := &http.Client{Transport: transport.NewRetryTransport(nil, transport.WithMaxRetries(4),
+		transport.WithBackoff(time.Second, 4*time.Second), recordSleeps(&waits))}
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// The backoff doubles up to the maximum, with a jitter of half the backoff
+	require.Len(t, waits, 4)
+	for i, backoff := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		assert.GreaterOrEqual(t, waits[i], backoff/2)
+		assert.LessOrEqual(t, waits[i], backoff)
+	}
+}
+
+func TestRetryTransport_RateLimitReset(t *testing.T) {
+	reset := time.Now().Add(10 * time.Second).Unix()
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if attempts.Add(1) == 1 {
+			// Last request allowed before the reset of the rate limit
+			w.Header().Set("RateLimit-Remaining", "0")
+			w.Header().Set("RateLimit-Reset", strconv.FormatInt(reset, 10))
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	client := &http.Client{Transport: transport.NewRetryTransport(nil, recordSleeps(&waits))}
+	for range 2 {
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	// The second request waits for the reset of the rate limit
+	require.Len(t, waits, 1)
+	assert.InDelta(t, 10*time.Second, waits[0], float64(2*time.Second))
+}
+
+func TestRetryTransport_ContextCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	client := &http.Client{Transport: transport.NewRetryTransport(nil)}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	_, err = client.Do(req) //nolint:bodyclose // no response is returned
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,113 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// ErrInvalidTLSConfig is returned when the TLS settings are inconsistent.
+var ErrInvalidTLSConfig = errors.New("invalid TLS configuration")
+
+// HTTPOption is a function that configures the transport returned by NewHTTPTransport.
+type HTTPOption func(*httpSettings)
+
+// httpSettings are the TLS and proxy settings of the transport.
+type httpSettings struct {
+	caCertFile         string
+	clientCertFile     string
+	clientKeyFile      string
+	insecureSkipVerify bool
+	proxyURL           string
+}
+
+// WithCACert trusts the certificate authorities of the PEM file, in addition to the ones of the system.
+func WithCACert(caCertFile string) HTTPOption {
+	return func(s *httpSettings) {
+		s.caCertFile = caCertFile
+	}
+}
+
+// WithClientCert authenticates the client with the certificate and key of the PEM files (mTLS).
+func WithClientCert(certFile, keyFile string) HTTPOption {
+	return func(s *httpSettings) {
+		s.clientCertFile = certFile
+		s.clientKeyFile = keyFile
+	}
+}
+
+// WithInsecureSkipVerify disables the verification of the certificate of the server.
+// The token can then be intercepted: it must only be used for tests.
+func WithInsecureSkipVerify(insecureSkipVerify bool) HTTPOption {
+	return func(s *httpSettings) {
+		s.insecureSkipVerify = insecureSkipVerify
+	}
+}
+
+// WithProxy sends the requests through the proxy, instead of the one given by the
+// HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables.
+func WithProxy(proxyURL string) HTTPOption {
+	return func(s *httpSettings) {
+		s.proxyURL = proxyURL
+	}
+}
+
+// NewHTTPTransport returns a copy of http.DefaultTransport configured with the TLS and proxy settings.
+// Without option, the certificate authorities of the system and the proxy of the environment are used.
+func NewHTTPTransport(opts ...HTTPOption) (*http.Transport, error) {
+	settings := &httpSettings{}
+	for _, opt := range opts {
+		opt(settings)
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert // set by net/http
+	tlsConfig, err := settings.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	t.TLSClientConfig = tlsConfig
+	if settings.proxyURL != "" {
+		proxyURL, err := url.Parse(settings.proxyURL)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", settings.proxyURL)
+		}
+		t.Proxy = http.ProxyURL(proxyURL)
+	}
+	return t, nil
+}
+
+// tlsConfig returns the TLS configuration of the settings.
+func (s *httpSettings) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: s.insecureSkipVerify, //nolint:gosec // explicitly requested, with a warning
+	}
+	if s.caCertFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		pem, err := os.ReadFile(s.caCertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w: no PEM certificate found in %s", ErrInvalidTLSConfig, s.caCertFile)
+		}
+		config.RootCAs = pool
+	}
+	if (s.clientCertFile == "") != (s.clientKeyFile == "") {
+		return nil, fmt.Errorf("%w: the client certificate and key must be given together", ErrInvalidTLSConfig)
+	}
+	if s.clientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(s.clientCertFile, s.clientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
@@ -0,0 +1,146 @@
+package transport_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/gitlab-token-expiration/pkg/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writePEM writes a PEM block in a file of a temporary directory and returns its path.
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+	return path
+}
+
+// newClientCert returns the paths of the PEM files of a self-signed client certificate and its key.
+func newClientCert(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return writePEM(t, "client.crt", "CERTIFICATE", der), writePEM(t, "client.key", "PRIVATE KEY", keyDER)
+}
+
+// roundTrip sends a GET request to the URL with the transport and returns the error.
+func roundTrip(t *testing.T, rt http.RoundTripper, u string) error {
+	t.Helper()
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, u, nil)
+	require.NoError(t, err)
+	resp, err := (&http.Client{Transport: rt}).Do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func TestNewHTTPTransport_ServerCertificate(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	caCert := writePEM(t, "ca.crt", "CERTIFICATE", server.Certificate().Raw)
+
+	tests := []struct {
+		name    string
+		opts    []transport.HTTPOption
+		wantErr bool
+	}{
+		{name: "unknown authority", opts: nil, wantErr: true},
+		{name: "CA certificate", opts: []transport.HTTPOption{transport.WithCACert(caCert)}},
+		{name: "insecure", opts: []transport.HTTPOption{transport.WithInsecureSkipVerify(true)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt, err := transport.NewHTTPTransport(tt.opts...)
+			require.NoError(t, err)
+			err = roundTrip(t, rt, server.URL)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestNewHTTPTransport_ClientCertificate(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.NotFoundHandler())
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert, MinVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+	caCert := writePEM(t, "ca.crt", "CERTIFICATE", server.Certificate().Raw)
+	clientCert, clientKey := newClientCert(t)
+
+	rt, err := transport.NewHTTPTransport(transport.WithCACert(caCert))
+	require.NoError(t, err)
+	require.Error(t, roundTrip(t, rt, server.URL), "the server requires a client certificate")
+
+	rt, err = transport.NewHTTPTransport(transport.WithCACert(caCert), transport.WithClientCert(clientCert, clientKey))
+	require.NoError(t, err)
+	require.NoError(t, roundTrip(t, rt, server.URL))
+}
+
+func TestNewHTTPTransport_Proxy(t *testing.T) {
+	rt, err := transport.NewHTTPTransport(transport.WithProxy("http://proxy.example.com:3128"))
+	require.NoError(t, err)
+	proxy, err := rt.Proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: "gitlab.com"}})
+	require.NoError(t, err)
+	assert.Equal(t, "proxy.example.com:3128", proxy.Host)
+}
+
+func TestNewHTTPTransport_Errors(t *testing.T) {
+	clientCert, clientKey := newClientCert(t)
+	notPEM := filepath.Join(t.TempDir(), "ca.crt")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not a certificate"), 0o600))
+
+	tests := []struct {
+		name    string
+		opts    []transport.HTTPOption
+		wantErr error
+	}{
+		{name: "missing CA file", opts: []transport.HTTPOption{transport.WithCACert(filepath.Join(t.TempDir(), "x"))}},
+		{name: "no certificate in CA file", opts: []transport.HTTPOption{transport.WithCACert(notPEM)},
+			wantErr: transport.ErrInvalidTLSConfig},
+		{name: "client certificate without key", opts: []transport.HTTPOption{transport.WithClientCert(clientCert, "")},
+			wantErr: transport.ErrInvalidTLSConfig},
+		{name: "key instead of certificate", opts: []transport.HTTPOption{transport.WithClientCert(clientKey, clientKey)}},
+		{name: "invalid proxy", opts: []transport.HTTPOption{transport.WithProxy("://proxy")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := transport.NewHTTPTransport(tt.opts...)
+			require.Error(t, err)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,300 @@
+// Package tui provides an interactive terminal user interface to browse and act on tokens.
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"atomicgo.dev/keyboard/keys"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/filter"
+)
+
+// Actions are the operations triggered from the user interface.
+type Actions interface {
+	// Rotate rotates the token and returns the new token and its secret value.
+	Rotate(token dto.Token) (dto.Token, string, error)
+	// Revoke revokes the token.
+	Revoke(token dto.Token) error
+	// Open opens the URL in a web browser.
+	Open(url string) error
+}
+
+// mode is the input mode of the user interface.
+type mode int
+
+const (
+	modeBrowse mode = iota
+	modeSearch
+	modeConfirmRotate
+	modeConfirmRevoke
+)
+
+// defaultHeight is the default number of tokens displayed at once.
+const defaultHeight = 15
+
+// statuses are the statuses the list can be filtered on, in cycling order.
+var statuses = []dto.Status{
+	dto.StatusExpired, dto.StatusExpiringSoon, dto.StatusNeverExpires, dto.StatusActive, dto.StatusNonExpiring,
+	dto.StatusRevoked,
+}
+
+// Model holds the state of the user interface. It does not perform any I/O
+// except through its Actions.
+type Model struct {
+	tokens          []dto.Token
+	actions         Actions
+	now             time.Time
+	nbDaysBeforeExp uint
+	height          int
+
+	visible      []int // indexes of the tokens matching the filters
+	cursor       int   // position of the selected token in visible
+	offset       int   // position of the first displayed token in visible
+	mode         mode
+	query        string
+	typeFilter   string
+	sourceFilter string
+	statusFilter dto.Status
+	showDetail   bool
+	message      string
+}
+
+// NewModel returns the model of the user interface listing the tokens.
+func NewModel(tokens []dto.Token, actions Actions, nbDaysBeforeExp uint) *Model {
+	m := &Model{
+		tokens:          slices.Clone(tokens),
+		actions:         actions,
+		now:             time.Now(),
+		nbDaysBeforeExp: nbDaysBeforeExp,
+		height:          defaultHeight,
+	}
+	m.refresh()
+	return m
+}
+
+// Selected returns the selected token, if any.
+func (m *Model) Selected() (dto.Token, bool) {
+	if len(m.visible) == 0 {
+		return dto.Token{}, false
+	}
+	return m.tokens[m.visible[m.cursor]], true
+}
+
+// Visible returns the tokens matching the filters.
+func (m *Model) Visible() []dto.Token {
+	res := make([]dto.Token, 0, len(m.visible))
+	for _, i := range m.visible {
+		res = append(res, m.tokens[i])
+	}
+	return res
+}
+
+// HandleKey updates the model according to the key pressed and returns true to quit.
+func (m *Model) HandleKey(key keys.Key) bool {
+	switch m.mode {
+	case modeSearch:
+		m.handleSearchKey(key)
+		return false
+	case modeConfirmRotate, modeConfirmRevoke:
+		m.handleConfirmKey(key)
+		return false
+	case modeBrowse:
+	}
+
+	if key.Code == keys.CtrlC || key.Code == keys.Escape {
+		return true
+	}
+	m.message = ""
+	switch key.Code {
+	case keys.Up:
+		m.move(-1)
+	case keys.Down:
+		m.move(1)
+	case keys.PgUp:
+		m.move(-m.height)
+	case keys.PgDown:
+		m.move(m.height)
+	case keys.Enter:
+		m.showDetail = !m.showDetail
+	case keys.RuneKey:
+		return m.handleRune(key)
+	default:
+	}
+	return false
+}
+
+func (m *Model) handleRune(key keys.Key) bool {
+	switch key.String() {
+	case "q":
+		return true
+	case "k":
+		m.move(-1)
+	case "j":
+		m.move(1)
+	case "/":
+		m.mode = modeSearch
+	case "t":
+		m.typeFilter = next(m.distinct(func(t dto.Token) string { return t.Type }), m.typeFilter)
+		m.refresh()
+	case "p":
+		m.sourceFilter = next(m.distinct(func(t dto.Token) string { return t.Source }), m.sourceFilter)
+		m.refresh()
+	case "s":
+		m.statusFilter = next(statuses, m.statusFilter)
+		m.refresh()
+	case "c":
+		m.query, m.typeFilter, m.sourceFilter, m.statusFilter = "", "", "", ""
+		m.refresh()
+	case "w":
+		m.open()
+	case "r":
+		if _, ok := m.Selected(); ok {
+			m.mode = modeConfirmRotate
+		}
+	case "x":
+		token, ok := m.Selected()
+		switch {
+		case !ok:
+		case !token.CanRevoke():
+			m.message = fmt.Sprintf("A %s cannot be revoked, only deleted from its settings page", token.Type)
+		default:
+			m.mode = modeConfirmRevoke
+		}
+	}
+	return false
+}
+
+func (m *Model) handleSearchKey(key keys.Key) {
+	switch key.Code {
+	case keys.Enter, keys.Escape, keys.CtrlC:
+		m.mode = modeBrowse
+	case keys.Backspace, keys.CtrlH:
+		if m.query != "" {
+			runes := []rune(m.query)
+			m.query = string(runes[:len(runes)-1])
+		}
+	case keys.RuneKey, keys.Space:
+		m.query += string(key.Runes)
+	default:
+	}
+	m.refresh()
+}
+
+func (m *Model) handleConfirmKey(key keys.Key) {
+	action := m.mode
+	m.mode = modeBrowse
+	if key.String() != "y" {
+		m.message = "Cancelled"
+		return
+	}
+	token, ok := m.Selected()
+	if !ok {
+		return
+	}
+	i := m.visible[m.cursor]
+	switch action {
+	case modeConfirmRotate:
+		newToken, secret, err := m.actions.Rotate(token)
+		if err != nil {
+			m.message = "Error: " + err.Error()
+			return
+		}
+		if newToken.ID == token.ID {
+			// The token changed in place, e.g. the authentication token of a runner
+			m.tokens[i] = newToken
+		} else {
+			m.tokens[i].Revoked = true
+			m.tokens = append(m.tokens, newToken)
+		}
+		m.message = fmt.Sprintf("Token %q rotated, new value (shown only once): %s", token.Name, secret)
+	case modeConfirmRevoke:
+		if err := m.actions.Revoke(token); err != nil {
+			m.message = "Error: " + err.Error()
+			return
+		}
+		m.tokens[i].Revoked = true
+		m.message = fmt.Sprintf("Token %q revoked", token.Name)
+	case modeBrowse, modeSearch:
+	}
+	m.refresh()
+}
+
+func (m *Model) open() {
+	token, ok := m.Selected()
+	if !ok {
+		return
+	}
+	if token.WebURL == "" {
+		m.message = "No settings page for this token"
+		return
+	}
+	if err := m.actions.Open(token.WebURL); err != nil {
+		m.message = "Error: " + err.Error()
+		return
+	}
+	m.message = "Opened " + token.WebURL
+}
+
+// move moves the cursor by delta tokens.
+func (m *Model) move(delta int) {
+	m.cursor = max(0, min(m.cursor+delta, len(m.visible)-1))
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+m.height {
+		m.offset = m.cursor - m.height + 1
+	}
+}
+
+// refresh computes the tokens matching the filters.
+func (m *Model) refresh() {
+	filters := []filter.Filter{
+		func(t dto.Token) bool {
+			return m.query == "" || strings.Contains(strings.ToLower(t.Name+" "+t.Source), strings.ToLower(m.query))
+		},
+		func(t dto.Token) bool { return m.typeFilter == "" || t.Type == m.typeFilter },
+		func(t dto.Token) bool { return m.sourceFilter == "" || t.Source == m.sourceFilter },
+		func(t dto.Token) bool {
+			return m.statusFilter == "" || t.Status(m.now, m.nbDaysBeforeExp) == m.statusFilter
+		},
+	}
+	m.visible = m.visible[:0]
+	for i, t := range m.tokens {
+		if filter.Match(t, filters...) {
+			m.visible = append(m.visible, i)
+		}
+	}
+	m.offset = 0
+	m.move(0)
+}
+
+// distinct returns the sorted distinct values of the field of the tokens.
+func (m *Model) distinct(field func(dto.Token) string) []string {
+	var values []string
+	for _, t := range m.tokens {
+		if v := field(t); v != "" && !slices.Contains(values, v) {
+			values = append(values, v)
+		}
+	}
+	slices.Sort(values)
+	return values
+}
+
+// next returns the value following current in values, cycling through the zero value (no filter).
+func next[T comparable](values []T, current T) T {
+	var zero T
+	if current == zero {
+		if len(values) == 0 {
+			return zero
+		}
+		return values[0]
+	}
+	i := slices.Index(values, current)
+	if i < 0 || i == len(values)-1 {
+		return zero
+	}
+	return values[i+1]
+}
@@ -0,0 +1,160 @@
+package tui_test
+
+import (
+	"errors"
+	"testing"
+
+	"atomicgo.dev/keyboard/keys"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/tui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockActions struct {
+	inPlace   bool // Rotate keeps the ID of the token, like for runners
+	rotated   []dto.Token
+	revoked   []dto.Token
+	opened    []string
+	revokeErr error
+}
+
+func (m *mockActions) Rotate(token dto.Token) (dto.Token, string, error) {
+	m.rotated = append(m.rotated, token)
+	if m.inPlace {
+		token.ExpiresAt = "2099-01-01"
+		return token, "glrt-secret", nil
+	}
+	return dto.Token{ID: token.ID + 100, Name: token.Name, Type: token.Type, Source: token.Source,
+		ExpiresAt: "2099-01-01"}, "glpat-secret", nil
+}
+
+func (m *mockActions) Revoke(token dto.Token) error {
+	m.revoked = append(m.revoked, token)
+	return m.revokeErr
+}
+
+func (m *mockActions) Open(url string) error {
+	m.opened = append(m.opened, url)
+	return nil
+}
+
+func runeKey(s string) keys.Key {
+	return keys.Key{Code: keys.RuneKey, Runes: []rune(s)}
+}
+
+func testTokens() []dto.Token {
+	return []dto.Token{
+		{ID: 1, Name: "ci-token", Type: dto.TypeAccessToken, Source: "group/backend", ExpiresAt: "2099-01-01",
+			WebURL: "https://gitlab.com/group/backend/-/settings/access_tokens"},
+		{ID: 2, Name: "deploy", Type: dto.TypeDeployToken, Source: "group/frontend"},
+		{ID: 3, Name: "old", Type: dto.TypeAccessToken, Source: "group/frontend", ExpiresAt: "2000-01-01"},
+	}
+}
+
+func TestModel_Navigation(t *testing.T) {
+	m := tui.NewModel(testTokens(), &mockActions{}, 30)
+
+	token, ok := m.Selected()
+	require.True(t, ok)
+	assert.Equal(t, int64(1), token.ID)
+
+	m.HandleKey(keys.Key{Code: keys.Down})
+	m.HandleKey(runeKey("j"))
+	m.HandleKey(runeKey("j")) // stays on the last token
+	token, _ = m.Selected()
+	assert.Equal(t, int64(3), token.ID)
+
+	m.HandleKey(runeKey("k"))
+	token, _ = m.Selected()
+	assert.Equal(t, int64(2), token.ID)
+
+	assert.True(t, m.HandleKey(runeKey("q")))
+}
+
+func TestModel_Filters(t *testing.T) {
+	m := tui.NewModel(testTokens(), &mockActions{}, 30)
+
+	// Search
+	m.HandleKey(runeKey("/"))
+	for _, r := range "dep" {
+		m.HandleKey(runeKey(string(r)))
+	}
+	assert.False(t, m.HandleKey(runeKey("q")), "q is part of the search while searching")
+	m.HandleKey(keys.Key{Code: keys.Backspace})
+	m.HandleKey(keys.Key{Code: keys.Enter})
+	require.Len(t, m.Visible(), 1)
+	assert.Equal(t, "deploy", m.Visible()[0].Name)
+
+	// Clear, then filter by type: access_token is the first type in alphabetical order
+	m.HandleKey(runeKey("c"))
+	assert.Len(t, m.Visible(), 3)
+	m.HandleKey(runeKey("t"))
+	assert.Len(t, m.Visible(), 2)
+
+	// Filter by source on top of the type
+	m.HandleKey(runeKey("p"))
+	m.HandleKey(runeKey("p"))
+	require.Len(t, m.Visible(), 1)
+	assert.Equal(t, "old", m.Visible()[0].Name)
+
+	// Filter by status: expired is the first status
+	m.HandleKey(runeKey("c"))
+	m.HandleKey(runeKey("s"))
+	require.Len(t, m.Visible(), 1)
+	assert.Equal(t, "old", m.Visible()[0].Name)
+
+	assert.Contains(t, m.View(), "status: expired")
+}
+
+func TestModel_Actions(t *testing.T) {
+	actions := &mockActions{}
+	m := tui.NewModel(testTokens(), actions, 30)
+
+	m.HandleKey(runeKey("w"))
+	assert.Equal(t, []string{"https://gitlab.com/group/backend/-/settings/access_tokens"}, actions.opened)
+
+	// Rotation requires a confirmation
+	m.HandleKey(runeKey("r"))
+	m.HandleKey(runeKey("n"))
+	assert.Empty(t, actions.rotated)
+	m.HandleKey(runeKey("r"))
+	assert.Contains(t, m.View(), "Rotate access_token \"ci-token\"")
+	m.HandleKey(runeKey("y"))
+	require.Len(t, actions.rotated, 1)
+	assert.Len(t, m.Visible(), 4)
+	assert.True(t, m.Visible()[0].Revoked)
+	assert.Contains(t, m.View(), "glpat-secret")
+
+	// Deploy tokens cannot be revoked, only deleted
+	m.HandleKey(runeKey("j"))
+	m.HandleKey(runeKey("x"))
+	assert.Contains(t, m.View(), "A deploy_token cannot be revoked")
+	m.HandleKey(runeKey("y"))
+	assert.Empty(t, actions.revoked)
+
+	// Revocation errors are displayed
+	actions.revokeErr = errors.New("forbidden")
+	m.HandleKey(runeKey("j"))
+	m.HandleKey(runeKey("x"))
+	m.HandleKey(runeKey("y"))
+	require.Len(t, actions.revoked, 1)
+	assert.Equal(t, int64(3), actions.revoked[0].ID)
+	assert.False(t, m.Visible()[2].Revoked)
+	assert.Contains(t, m.View(), "Error: forbidden")
+}
+
+func TestModel_RotateInPlace(t *testing.T) {
+	actions := &mockActions{inPlace: true}
+	tokens := []dto.Token{{ID: 7, Name: "runner 7", Type: dto.TypeRunnerToken, Source: "group", ExpiresAt: "2000-01-01"}}
+	m := tui.NewModel(tokens, actions, 30)
+
+	m.HandleKey(runeKey("r"))
+	m.HandleKey(runeKey("y"))
+
+	require.Len(t, actions.rotated, 1)
+	require.Len(t, m.Visible(), 1, "the runner is not duplicated")
+	assert.False(t, m.Visible()[0].Revoked)
+	assert.Equal(t, "2099-01-01", m.Visible()[0].ExpiresAt)
+	assert.Contains(t, m.View(), "glrt-secret")
+}
@@ -0,0 +1,49 @@
+package tui
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+
+	"atomicgo.dev/keyboard"
+	"atomicgo.dev/keyboard/keys"
+	"github.com/pterm/pterm"
+)
+
+// Run displays the user interface until the user quits.
+func Run(m *Model) error {
+	area, err := pterm.DefaultArea.Start(m.View())
+	if err != nil {
+		return fmt.Errorf("failed to start the user interface: %w", err)
+	}
+	err = keyboard.Listen(func(key keys.Key) (bool, error) {
+		quit := m.HandleKey(key)
+		area.Update(m.View())
+		return quit, nil
+	})
+	if stopErr := area.Stop(); stopErr != nil && err == nil {
+		err = stopErr
+	}
+	if err != nil {
+		return fmt.Errorf("user interface error: %w", err)
+	}
+	return nil
+}
+
+// OpenBrowser opens the URL in the default web browser.
+func OpenBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open %s: %w", url, err)
+	}
+	go cmd.Wait() //nolint:errcheck // the browser is not waited for
+	return nil
+}
@@ -0,0 +1,126 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/pterm/pterm"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+)
+
+const helpText = "↑/↓ move  enter details  / search  t type  p source  s status  c clear  " +
+	"w open settings  r rotate  x revoke  q quit"
+
+// View returns the representation of the model to display.
+func (m *Model) View() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Tokens: %d/%d  search: %q  type: %s  source: %s  status: %s\n\n",
+		len(m.visible), len(m.tokens), m.query,
+		orAll(m.typeFilter), orAll(m.sourceFilter), orAll(string(m.statusFilter)))
+
+	tData := pterm.TableData{{"", "Status", "Type", "Source", "Name", "Expires at"}}
+	end := min(m.offset+m.height, len(m.visible))
+	for pos := m.offset; pos < end; pos++ {
+		t := m.tokens[m.visible[pos]]
+		cursor := " "
+		if pos == m.cursor {
+			cursor = ">"
+		}
+		expiresAt := t.ExpiresAt
+		switch {
+		case !t.CanExpire():
+			expiresAt = "non-expiring"
+		case expiresAt == "":
+			expiresAt = "never"
+		}
+		tData = append(tData, []string{cursor, m.prettyStatus(t), t.Type, t.Source, t.Name, expiresAt})
+	}
+	table, err := pterm.DefaultTable.WithHasHeader().WithData(tData).Srender()
+	if err != nil {
+		table = err.Error()
+	}
+	b.WriteString(table)
+	b.WriteString("\n")
+
+	if token, ok := m.Selected(); ok && m.showDetail {
+		b.WriteString("\n")
+		b.WriteString(detail(token))
+	}
+
+	b.WriteString("\n")
+	switch m.mode {
+	case modeSearch:
+		fmt.Fprintf(&b, "Search: %s_ (enter to validate)\n", m.query)
+	case modeConfirmRotate, modeConfirmRevoke:
+		token, _ := m.Selected()
+		action := "Rotate"
+		if m.mode == modeConfirmRevoke {
+			action = "Revoke"
+		}
+		fmt.Fprintf(&b, "%s %s %q of %s? [y/N]\n", action, token.Type, token.Name, token.Source)
+	case modeBrowse:
+		if m.message != "" {
+			b.WriteString(m.message + "\n")
+		}
+		b.WriteString(helpText + "\n")
+	}
+	return b.String()
+}
+
+func (m *Model) prettyStatus(t dto.Token) string {
+	status := t.Status(m.now, m.nbDaysBeforeExp)
+	switch status {
+	case dto.StatusExpired, dto.StatusNeverExpires:
+		return color.New(color.FgRed).Sprint(status)
+	case dto.StatusExpiringSoon:
+		return color.New(color.FgYellow).Sprint(status)
+	case dto.StatusRevoked:
+		return color.New(color.Faint).Sprint(status)
+	case dto.StatusActive, dto.StatusNonExpiring:
+		return string(status)
+	default:
+		return string(status)
+	}
+}
+
+// detail returns the detailed description of a token.
+func detail(t dto.Token) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "ID:        %d\n", t.ID)
+	fmt.Fprintf(&b, "Name:      %s\n", t.Name)
+	fmt.Fprintf(&b, "Source:    %s\n", t.Source)
+	fmt.Fprintf(&b, "Type:      %s\n", t.Type)
+	fmt.Fprintf(&b, "Scopes:    %s\n", strings.Join(t.Scopes, ", "))
+	fmt.Fprintf(&b, "Owner:     %s\n", owner(t))
+	if t.CreatedAt != "" {
+		fmt.Fprintf(&b, "Created:   %s\n", t.CreatedAt)
+	}
+	lastUsed := t.LastUsedAt
+	if lastUsed == "" && t.TracksUsage() {
+		lastUsed = "never"
+	}
+	fmt.Fprintf(&b, "Last used: %s\n", lastUsed)
+	fmt.Fprintf(&b, "Settings:  %s\n", t.WebURL)
+	return b.String()
+}
+
+// owner returns the owner of the token, or the usernames of its contacts if the owner is unknown.
+func owner(t dto.Token) string {
+	if t.Owner != "" {
+		return t.Owner
+	}
+	usernames := make([]string, 0, len(t.Contacts))
+	for _, c := range t.Contacts {
+		usernames = append(usernames, c.Username)
+	}
+	return strings.Join(usernames, ", ")
+}
+
+func orAll(filter string) string {
+	if filter == "" {
+		return "all"
+	}
+	return filter
+}
@@ -0,0 +1,59 @@
+package views
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/fatih/color"
+	"github.com/pterm/pterm"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/snapshot"
+)
+
+// RenderChanges displays the changes of the tokens between two snapshots in a table format.
+func (t TableOutput) RenderChanges(changes []snapshot.Change) error {
+	tData := pterm.TableData{}
+	if t.HeaderOption {
+		tData = append(tData, []string{"Change", "ID", "Source", "Type", "Name", "Expires before", "Expires after"})
+	}
+	for _, change := range changes {
+		token := change.Token()
+		var before, after string
+		if change.Old != nil {
+			before = t.prettyPrintTokenExpiresAt(*change.Old)
+		}
+		if change.New != nil {
+			after = t.prettyPrintTokenExpiresAt(*change.New)
+		}
+		id := strconv.FormatInt(token.ID, 10)
+		if change.Kind == snapshot.ChangeRotated {
+			id = fmt.Sprintf("%d -> %d", change.Old.ID, change.New.ID)
+		}
+		tData = append(tData, []string{t.prettyPrintChangeKind(change.Kind), id,
+			token.Source, token.Type, token.Name, before, after})
+	}
+	table := pterm.DefaultTable
+	if t.HeaderOption {
+		table = *table.WithHasHeader()
+	}
+	err := table.WithData(tData).Render()
+	if err != nil {
+		return fmt.Errorf("error rendering table: %w", err)
+	}
+	return nil
+}
+
+func (t TableOutput) prettyPrintChangeKind(kind snapshot.ChangeKind) string {
+	if !t.ColorOption {
+		return string(kind)
+	}
+	switch kind {
+	case snapshot.ChangeCreated, snapshot.ChangeRotated:
+		return color.New(color.FgGreen).Sprint(kind)
+	case snapshot.ChangeRevoked, snapshot.ChangeRemoved, snapshot.ChangeExpired:
+		return color.New(color.FgRed).Sprint(kind)
+	case snapshot.ChangeExpiryChanged:
+		return color.New(color.FgYellow).Sprint(kind)
+	default:
+		return string(kind)
+	}
+}
@@ -4,7 +4,9 @@ package views
 import (
 	"fmt"
 	"math"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/fatih/color"
@@ -12,11 +14,21 @@ import (
 	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
 )
 
+// NeverExpiresLabel is displayed in place of the expiration date of tokens that never expire.
+const NeverExpiresLabel = "never"
+
+// NonExpiringLabel is displayed in place of the expiration date of credentials that cannot expire.
+const NonExpiringLabel = "non-expiring credential"
+
+// NeverUsedLabel is displayed in place of the last usage date of tokens that have never been used.
+const NeverUsedLabel = "never"
+
 // TableOutput represents a table renderer for token display.
 type TableOutput struct {
 	HeaderOption    bool
 	ColorOption     bool
 	printRevoked    bool
+	printSummary    bool
 	nbDaysBeforeExp uint
 }
 
@@ -51,6 +63,13 @@ func WithNbDaysBeforeExp(nbDaysBeforeExp uint) TableOutputOption {
 	}
 }
 
+// WithSummaryOption configures whether to display the number of tokens per status.
+func WithSummaryOption(printSummary bool) TableOutputOption {
+	return func(t *TableOutput) {
+		t.printSummary = printSummary
+	}
+}
+
 // NewTableOutput creates a new TableOutput with the given options.
 func NewTableOutput(opts ...TableOutputOption) TableOutput {
 	t := TableOutput{}
@@ -64,7 +83,7 @@ func NewTableOutput(opts ...TableOutputOption) TableOutput {
 func (t TableOutput) Render(tokens []dto.Token) error {
 	tData := pterm.TableData{}
 	if t.HeaderOption {
-		tData = append(tData, []string{"ID", "Source", "Type", "Name", "Revoked", "Expires at"})
+		tData = append(tData, []string{"ID", "Source", "Type", "Name", "Revoked", "Expires at", "Last used", "Owner"})
 	}
 	for _, token := range tokens {
 		if !t.printRevoked && token.Revoked {
@@ -73,7 +92,9 @@ func (t TableOutput) Render(tokens []dto.Token) error {
 		tData = append(tData, []string{strconv.FormatInt(token.ID, 10),
 			token.Source, token.Type, token.Name,
 			t.prettyPrintBool(token.Revoked, true),
-			t.prettyPrintExpiresAt(token.ExpiresAt)})
+			t.prettyPrintTokenExpiresAt(token),
+			prettyPrintLastUsedAt(token),
+			prettyPrintOwner(token)})
 	}
 	// Create a table with a header and the defined data, then render it
 	table := pterm.DefaultTable
@@ -84,9 +105,51 @@ func (t TableOutput) Render(tokens []dto.Token) error {
 	if err != nil {
 		return fmt.Errorf("error rendering table: %w", err)
 	}
+	if t.printSummary {
+		t.renderSummary(tokens)
+	}
 	return nil
 }
 
+// prettyPrintLastUsedAt returns the last usage date of the token,
+// "never" if it has never been used, or an empty string if its usage is not tracked.
+func prettyPrintLastUsedAt(token dto.Token) string {
+	if token.LastUsedAt == "" && token.TracksUsage() {
+		return NeverUsedLabel
+	}
+	return token.LastUsedAt
+}
+
+// prettyPrintOwner returns the owner of the token, or the usernames of its contacts if the owner is unknown.
+func prettyPrintOwner(token dto.Token) string {
+	if token.Owner != "" {
+		return token.Owner
+	}
+	usernames := make([]string, 0, len(token.Contacts))
+	for _, c := range token.Contacts {
+		usernames = append(usernames, c.Username)
+	}
+	return strings.Join(usernames, ", ")
+}
+
+// renderSummary displays the number of tokens per status.
+func (t TableOutput) renderSummary(tokens []dto.Token) {
+	if !t.printRevoked {
+		tokens = slices.DeleteFunc(slices.Clone(tokens), func(token dto.Token) bool {
+			return token.Revoked
+		})
+	}
+	s := dto.Summarize(tokens, time.Now(), t.nbDaysBeforeExp)
+	fmt.Printf("Total: %d, active: %d, expiring soon: %d, expired: %d, never expires: %d",
+		s.Total, s.Active, s.ExpiringSoon, s.Expired, s.NeverExpires)
+	if s.NonExpiring > 0 {
+		fmt.Printf(", non-expiring: %d", s.NonExpiring)
+	}
+	if t.printRevoked {
+		fmt.Printf(", revoked: %d", s.Revoked)
+	}
+	fmt.Println()
+}
 
 // prettyPrintBool returns a string representation of a boolean value
 // with red color if value is equal to coloredValue.
@@ -99,6 +162,15 @@ func (t TableOutput) prettyPrintBool(b bool, coloredValue bool) string {
 	return bStr
 }
 
+// prettyPrintTokenExpiresAt returns the expiration date of the token, or a label for the credentials
+// that cannot expire.
+func (t TableOutput) prettyPrintTokenExpiresAt(token dto.Token) string {
+	if !token.CanExpire() {
+		return NonExpiringLabel
+	}
+	return t.prettyPrintExpiresAt(token.ExpiresAt)
+}
+
 func (t TableOutput) prettyPrintExpiresAt(d string) string {
 	red := color.New(color.FgRed).SprintFunc()
 	yellow := color.New(color.FgYellow).SprintFunc()
@@ -107,7 +179,13 @@ func (t TableOutput) prettyPrintExpiresAt(d string) string {
 	// if now > time.Time, print in red
 	// if now + 30 days > time.Time, print in yellow
 	// else return the date
-	date, err := time.Parse("2006-01-02", d)
+	if d == "" {
+		if t.ColorOption {
+			return red(NeverExpiresLabel)
+		}
+		return NeverExpiresLabel
+	}
+	date, err := time.Parse(dto.DateFormat, d)
 	if err != nil {
 		return d
 	}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/sgaunet/gitlab-token-expiration/pkg/dto"
+	"github.com/sgaunet/gitlab-token-expiration/pkg/snapshot"
 	"github.com/sgaunet/gitlab-token-expiration/pkg/views"
 	"github.com/stretchr/testify/assert"
 )
@@ -128,6 +129,27 @@ func TestTableOutput_Render(t *testing.T) {
 			table:  views.NewTableOutput(),
 			tokens: []dto.Token{},
 		},
+		{
+			name: "render with summary",
+			table: views.NewTableOutput(views.WithSummaryOption(true),
+				views.WithPrintRevokedOption(true)),
+			tokens: []dto.Token{
+				{
+					ID:        1,
+					Name:      "active-token",
+					ExpiresAt: "2025-12-31",
+				},
+				{
+					ID:   2,
+					Name: "never-expiring-token",
+				},
+				{
+					ID:      3,
+					Name:    "revoked-token",
+					Revoked: true,
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -222,4 +244,20 @@ func TestTableOutput_ComplexScenario(t *testing.T) {
 	// Test that rendering doesn't return an error
 	err := table.Render(tokens)
 	assert.NoError(t, err)
-}
\ No newline at end of file
+}
+func TestTableOutput_RenderChanges(t *testing.T) {
+	oldToken := dto.Token{ID: 1, Source: "group/project", Type: "access_token", Name: "ci", ExpiresAt: "2025-06-30"}
+	newToken := dto.Token{ID: 2, Source: "group/project", Type: "access_token", Name: "ci", ExpiresAt: "2025-09-30"}
+	changes := []snapshot.Change{
+		{Kind: snapshot.ChangeRotated, Old: &oldToken, New: &newToken},
+		{Kind: snapshot.ChangeCreated, New: &newToken},
+		{Kind: snapshot.ChangeRemoved, Old: &oldToken},
+	}
+
+	for _, table := range []views.TableOutput{
+		views.NewTableOutput(views.WithHeaderOption(true), views.WithColorOption(true)),
+		views.NewTableOutput(),
+	} {
+		assert.NoError(t, table.RenderChanges(changes))
+	}
+}